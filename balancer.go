@@ -0,0 +1,192 @@
+package jsonrpc
+
+import (
+	"cmp"
+	"context"
+	"hash/fnv"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// BalancingStrategy selects the endpoint of a [Client] that receives each request.
+type BalancingStrategy int
+
+const (
+	// RoundRobin sends requests to every endpoint in turn.
+	RoundRobin BalancingStrategy = iota
+	// LeastInFlight sends requests to the endpoint with the fewest requests in flight.
+	LeastInFlight
+	// ConsistentHashByMethod always sends the calls of a method to the same endpoint while it is available.
+	// Batches are routed by the method of their first call.
+	ConsistentHashByMethod
+)
+
+// hashReplicas is the number of points of every endpoint on the consistent hash ring.
+const hashReplicas = 64
+
+// endpoint is a server the client can send requests to.
+type endpoint struct {
+	url       string
+	healthURL string
+	breaker   *circuitBreaker
+	inFlight  atomic.Int64
+	// ejectedUntil is the unix time in nanoseconds until which the endpoint failed its health check.
+	ejectedUntil atomic.Int64
+}
+
+func (e *endpoint) ejected(now time.Time) bool {
+	return now.UnixNano() < e.ejectedUntil.Load()
+}
+
+// healthURL returns the url of the health endpoint of the server serving rpcURL.
+func healthURL(rpcURL string, healthPath string) string {
+	u, err := url.Parse(rpcURL)
+	if err != nil {
+		return rpcURL
+	}
+	u.Path = healthPath
+	u.RawPath = ""
+	u.RawQuery = ""
+	return u.String()
+}
+
+type ringPoint struct {
+	hash     uint32
+	endpoint *endpoint
+}
+
+// balancer picks the endpoint of every request according to a strategy.
+type balancer struct {
+	strategy  BalancingStrategy
+	endpoints []*endpoint
+	next      atomic.Uint64
+	ring      []ringPoint
+
+	stopHealthChecks chan struct{}
+	healthChecks     sync.WaitGroup
+	stopOnce         sync.Once
+}
+
+func newBalancer(urls []string, opts *clientOpts) *balancer {
+	b := &balancer{strategy: opts.balancingStrategy, stopHealthChecks: make(chan struct{})}
+	for _, u := range urls {
+		e := &endpoint{
+			url:       u,
+			healthURL: healthURL(u, opts.healthPath),
+			breaker:   newCircuitBreaker(opts.breakerThreshold, opts.breakerCooldown),
+		}
+		b.endpoints = append(b.endpoints, e)
+		for i := 0; i < hashReplicas; i++ {
+			b.ring = append(b.ring, ringPoint{hash: hashKey(u + "#" + strconv.Itoa(i)), endpoint: e})
+		}
+	}
+	slices.SortFunc(b.ring, func(a, b ringPoint) int {
+		return cmp.Compare(a.hash, b.hash)
+	})
+	if opts.healthCheckInterval > 0 {
+		b.healthChecks.Add(1)
+		go b.checkHealth(opts.httpClient, opts.healthCheckInterval, opts.ejectFor)
+	}
+	return b
+}
+
+func hashKey(key string) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return h.Sum32()
+}
+
+// pick returns the endpoint for a request routed by key. Endpoints that failed their health check are skipped,
+// unless all of them did, and endpoints with an open circuit breaker are always skipped.
+func (b *balancer) pick(key string) (*endpoint, error) {
+	now := time.Now()
+	available := func(e *endpoint) bool {
+		return !e.ejected(now) && !e.breaker.open()
+	}
+	if !slices.ContainsFunc(b.endpoints, available) {
+		available = func(e *endpoint) bool {
+			return !e.breaker.open()
+		}
+		if !slices.ContainsFunc(b.endpoints, available) {
+			return nil, ErrCircuitOpen
+		}
+	}
+	switch b.strategy {
+	case ConsistentHashByMethod:
+		hash := hashKey(key)
+		start, _ := slices.BinarySearchFunc(b.ring, hash, func(point ringPoint, hash uint32) int {
+			return cmp.Compare(point.hash, hash)
+		})
+		for i := range b.ring {
+			if point := b.ring[(start+i)%len(b.ring)]; available(point.endpoint) {
+				return point.endpoint, nil
+			}
+		}
+	case LeastInFlight:
+		var least *endpoint
+		offset := int(b.next.Add(1))
+		for i := range b.endpoints {
+			e := b.endpoints[(offset+i)%len(b.endpoints)]
+			if available(e) && (least == nil || e.inFlight.Load() < least.inFlight.Load()) {
+				least = e
+			}
+		}
+		return least, nil
+	}
+	offset := int(b.next.Add(1))
+	for i := range b.endpoints {
+		if e := b.endpoints[(offset+i)%len(b.endpoints)]; available(e) {
+			return e, nil
+		}
+	}
+	return nil, ErrCircuitOpen
+}
+
+// checkHealth probes the health endpoint of every endpoint at every interval until the balancer is closed.
+// Endpoints that do not answer with a 200 are ejected for ejectFor.
+func (b *balancer) checkHealth(httpClient *http.Client, interval time.Duration, ejectFor time.Duration) {
+	defer b.healthChecks.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for _, e := range b.endpoints {
+			if healthy(httpClient, e.healthURL, interval) {
+				e.ejectedUntil.Store(0)
+			} else {
+				e.ejectedUntil.Store(time.Now().Add(ejectFor).UnixNano())
+			}
+		}
+		select {
+		case <-ticker.C:
+		case <-b.stopHealthChecks:
+			return
+		}
+	}
+}
+
+func healthy(httpClient *http.Client, healthURL string, timeout time.Duration) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, healthURL, nil)
+	if err != nil {
+		return false
+	}
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return false
+	}
+	_ = response.Body.Close()
+	return response.StatusCode == http.StatusOK
+}
+
+func (b *balancer) close() {
+	b.stopOnce.Do(func() {
+		close(b.stopHealthChecks)
+	})
+	b.healthChecks.Wait()
+}
@@ -0,0 +1,94 @@
+package jsonrpc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type replica struct {
+	server  *httptest.Server
+	calls   atomic.Int32
+	healthy atomic.Bool
+}
+
+func newReplicas(t *testing.T, n int) ([]*replica, []string) {
+	t.Helper()
+	var replicas []*replica
+	var urls []string
+	for i := 0; i < n; i++ {
+		r := &replica{}
+		r.healthy.Store(true)
+		s := New(WithHealthEndpoints(false))
+		s.Register(NewTypedHandler("echo", func(ctx context.Context, headers http.Header, id ID, params string) (string, error) {
+			r.calls.Add(1)
+			return params, nil
+		}))
+		mux := http.NewServeMux()
+		mux.Handle("/rpc", s)
+		mux.HandleFunc("/health", func(writer http.ResponseWriter, request *http.Request) {
+			if !r.healthy.Load() {
+				writer.WriteHeader(http.StatusServiceUnavailable)
+			}
+		})
+		r.server = httptest.NewServer(mux)
+		t.Cleanup(r.server.Close)
+		replicas = append(replicas, r)
+		urls = append(urls, r.server.URL+"/rpc")
+	}
+	return replicas, urls
+}
+
+func TestRoundRobin(t *testing.T) {
+	replicas, urls := newReplicas(t, 3)
+	client := NewBalancedClient(urls)
+	defer client.Close()
+	for i := 0; i < 9; i++ {
+		require.NoError(t, client.Call(context.Background(), "echo", "hi", nil))
+	}
+	for _, r := range replicas {
+		require.Equal(t, int32(3), r.calls.Load())
+	}
+}
+
+func TestConsistentHashByMethod(t *testing.T) {
+	replicas, urls := newReplicas(t, 3)
+	client := NewBalancedClient(urls, WithBalancingStrategy(ConsistentHashByMethod))
+	defer client.Close()
+	for i := 0; i < 5; i++ {
+		require.NoError(t, client.Call(context.Background(), "echo", "hi", nil))
+	}
+	var hit int
+	for _, r := range replicas {
+		if r.calls.Load() > 0 {
+			hit++
+			require.Equal(t, int32(5), r.calls.Load())
+		}
+	}
+	require.Equal(t, 1, hit)
+}
+
+func TestHealthCheckEjection(t *testing.T) {
+	replicas, urls := newReplicas(t, 2)
+	replicas[0].healthy.Store(false)
+	client := NewBalancedClient(urls, WithBalancingStrategy(LeastInFlight), WithHealthCheck(10*time.Millisecond, time.Minute))
+	defer client.Close()
+	require.Eventually(t, func() bool {
+		return client.balancer.endpoints[0].ejected(time.Now())
+	}, time.Second, 5*time.Millisecond)
+	for i := 0; i < 4; i++ {
+		require.NoError(t, client.Call(context.Background(), "echo", "hi", nil))
+	}
+	require.Equal(t, int32(0), replicas[0].calls.Load())
+	require.Equal(t, int32(4), replicas[1].calls.Load())
+
+	replicas[0].healthy.Store(true)
+	require.Eventually(t, func() bool {
+		return !client.balancer.endpoints[0].ejected(time.Now())
+	}, time.Second, 5*time.Millisecond)
+}
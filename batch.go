@@ -0,0 +1,143 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrBatchNotSent is returned by a Future whose batch has not been sent yet.
+	ErrBatchNotSent = errors.New("jsonrpc: batch not sent")
+	// ErrBatchAlreadySent is returned when sending a batch a second time.
+	ErrBatchAlreadySent = errors.New("jsonrpc: batch already sent")
+)
+
+// Batch collects calls and notifications to send them together. Use [Client.NewBatch] to create one.
+//
+// Batches larger than the limit set with [WithClientMaxBatchSize] are split into several requests.
+// When a server rejects a batch because it exceeds the maxBatchSize it advertises in the error, the batch is
+// split to that size and the limit is remembered by the client for later batches.
+type Batch struct {
+	client *Client
+	calls  []*batchCall
+	sent   bool
+}
+
+type batchCall struct {
+	method       string
+	params       interface{}
+	notification bool
+	future       *Future
+}
+
+// Future is the handle to the result of a call added to a [Batch].
+type Future struct {
+	sent   bool
+	result json.RawMessage
+	err    error
+}
+
+// NewBatch returns an empty batch sent with this client.
+func (c *Client) NewBatch() *Batch {
+	return &Batch{client: c}
+}
+
+// Call adds a call of method to the batch and returns the handle to its result.
+func (b *Batch) Call(method string, params interface{}) *Future {
+	future := &Future{}
+	b.calls = append(b.calls, &batchCall{method: method, params: params, future: future})
+	return future
+}
+
+// Notify adds a notification of method to the batch.
+func (b *Batch) Notify(method string, params interface{}) {
+	b.calls = append(b.calls, &batchCall{method: method, params: params, notification: true})
+}
+
+// Len returns the number of calls and notifications in the batch.
+func (b *Batch) Len() int {
+	return len(b.calls)
+}
+
+// Send sends the batch, split into several requests if needed. The outcome of every call is then available
+// from its Future. The returned error joins the errors of the requests that failed as a whole; the futures of
+// the calls in those requests return the same error.
+func (b *Batch) Send(ctx context.Context) error {
+	if b.sent {
+		return ErrBatchAlreadySent
+	}
+	b.sent = true
+	var errs []error
+	calls := b.calls
+	for len(calls) > 0 {
+		size := len(calls)
+		if limit := b.client.maxBatchSize(); limit > 0 && size > limit {
+			size = limit
+		}
+		chunk := calls[:size]
+		elems := make([]BatchElem, size)
+		for i, call := range chunk {
+			elems[i] = BatchElem{Method: call.method, Params: call.params, Notification: call.notification}
+			if !call.notification {
+				elems[i].Result = &call.future.result
+			}
+		}
+		err := b.client.BatchCall(ctx, elems)
+		if limit, ok := advertisedMaxBatchSize(err); ok && limit < size {
+			b.client.serverMaxBatchSize.Store(int64(limit))
+			continue
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+		for i, call := range chunk {
+			if call.notification {
+				continue
+			}
+			call.future.sent = true
+			call.future.err = elems[i].Error
+			if err != nil {
+				call.future.err = err
+			}
+		}
+		calls = calls[size:]
+	}
+	return errors.Join(errs...)
+}
+
+// Err returns the error of the call, or nil if it succeeded.
+func (f *Future) Err() error {
+	if !f.sent {
+		return ErrBatchNotSent
+	}
+	return f.err
+}
+
+// Decode decodes the result of the call into result, which must be a pointer, or returns the error of the call.
+func (f *Future) Decode(result interface{}) error {
+	if err := f.Err(); err != nil {
+		return err
+	}
+	if len(f.result) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(f.result, result); err != nil {
+		return fmt.Errorf("jsonrpc: failed to decode result: %w", err)
+	}
+	return nil
+}
+
+// advertisedMaxBatchSize returns the maxBatchSize a server reported when rejecting a batch for being too large.
+func advertisedMaxBatchSize(err error) (int, bool) {
+	var invalidRequest InvalidRequestError
+	if !errors.As(err, &invalidRequest) {
+		return 0, false
+	}
+	limit, ok := invalidRequest.RpcError.Data["maxBatchSize"].(float64)
+	if !ok || limit < 1 {
+		return 0, false
+	}
+	return int(limit), true
+}
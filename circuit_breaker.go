@@ -0,0 +1,102 @@
+package jsonrpc
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without contacting the server while the circuit breaker of its endpoint is open.
+var ErrCircuitOpen = errors.New("jsonrpc: circuit breaker open")
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// circuitBreaker stops sending requests to an endpoint after threshold consecutive failures.
+// Once cooldown has elapsed a single trial request is let through: the breaker closes again if it succeeds
+// and stays open for another cooldown otherwise. A nil circuitBreaker lets every request through.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	lock     sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	if threshold <= 0 {
+		return nil
+	}
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown}
+}
+
+// allow reports whether a request can be sent. Every allowed request must be followed by a call to record,
+// or to release if its outcome says nothing about the endpoint.
+func (b *circuitBreaker) allow() bool {
+	if b == nil {
+		return true
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	switch b.state {
+	case breakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = breakerHalfOpen
+		return true
+	case breakerHalfOpen:
+		// Only the trial request is let through until its outcome is known.
+		return false
+	}
+	return true
+}
+
+// open reports whether the breaker currently rejects requests, without changing its state.
+func (b *circuitBreaker) open() bool {
+	if b == nil {
+		return false
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.state == breakerHalfOpen || (b.state == breakerOpen && time.Since(b.openedAt) < b.cooldown)
+}
+
+// record reports the outcome of a request let through by allow.
+func (b *circuitBreaker) record(success bool) {
+	if b == nil {
+		return
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if success {
+		b.state = breakerClosed
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= b.threshold {
+		b.state = breakerOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// release ends a request let through by allow without recording its outcome, e.g. because the caller gave up on
+// it. A released trial request leaves the breaker open, and the next request is let through as a new trial.
+func (b *circuitBreaker) release() {
+	if b == nil {
+		return
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.state == breakerHalfOpen {
+		b.state = breakerOpen
+	}
+}
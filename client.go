@@ -0,0 +1,408 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// Client calls the methods of a JSON-RPC server over HTTP, optionally balancing requests across several replicas.
+// Errors returned by the server are converted back into the error types of this package,
+// so that errors.As can be used to inspect them.
+type Client struct {
+	opts     *clientOpts
+	balancer *balancer
+	nextID   atomic.Int64
+	// serverMaxBatchSize is the batch size limit learned from the server, 0 if unknown.
+	serverMaxBatchSize atomic.Int64
+}
+
+type ClientOption = func(opts *clientOpts)
+
+type clientOpts struct {
+	httpClient       *http.Client
+	headers          http.Header
+	maxBatchSize     int
+	timeout          time.Duration
+	retry            RetryPolicy
+	breakerThreshold int
+	breakerCooldown  time.Duration
+	nonIdempotent    map[string]bool
+
+	balancingStrategy   BalancingStrategy
+	healthPath          string
+	healthCheckInterval time.Duration
+	ejectFor            time.Duration
+}
+
+func defaultClientOpts() *clientOpts {
+	return &clientOpts{
+		httpClient:    http.DefaultClient,
+		headers:       http.Header{},
+		nonIdempotent: map[string]bool{},
+		healthPath:    "/health",
+	}
+}
+
+// WithHTTPClient sets the http.Client used to send requests. http.DefaultClient is used by default.
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(opts *clientOpts) {
+		opts.httpClient = httpClient
+	}
+}
+
+// WithHeader adds a header to every http request sent by the client.
+func WithHeader(key string, value string) ClientOption {
+	return func(opts *clientOpts) {
+		opts.headers.Add(key, value)
+	}
+}
+
+// WithClientMaxBatchSize splits the batches sent with [Batch.Send] into requests of at most batchSize calls.
+// It should match the WithMaxBatchSize option of the server. Batches are not split by default.
+func WithClientMaxBatchSize(batchSize int) ClientOption {
+	return func(opts *clientOpts) {
+		opts.maxBatchSize = batchSize
+	}
+}
+
+// WithTimeout bounds the duration of every attempt of a call. There is no timeout by default.
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(opts *clientOpts) {
+		opts.timeout = timeout
+	}
+}
+
+// WithRetryPolicy retries failed calls according to policy. Calls are not retried by default.
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(opts *clientOpts) {
+		opts.retry = policy
+	}
+}
+
+// WithNonIdempotentMethods marks methods that must never be retried, e.g. because they have side effects.
+func WithNonIdempotentMethods(methods ...string) ClientOption {
+	return func(opts *clientOpts) {
+		for _, method := range methods {
+			opts.nonIdempotent[method] = true
+		}
+	}
+}
+
+// WithCircuitBreaker stops sending requests to an endpoint for cooldown after failureThreshold consecutive
+// failures, failing calls with [ErrCircuitOpen] instead. Transport errors, 429 and 5xx http statuses count as failures.
+func WithCircuitBreaker(failureThreshold int, cooldown time.Duration) ClientOption {
+	return func(opts *clientOpts) {
+		opts.breakerThreshold = failureThreshold
+		opts.breakerCooldown = cooldown
+	}
+}
+
+// WithBalancingStrategy sets how requests are spread across the endpoints of the client, RoundRobin by default.
+func WithBalancingStrategy(strategy BalancingStrategy) ClientOption {
+	return func(opts *clientOpts) {
+		opts.balancingStrategy = strategy
+	}
+}
+
+// WithHealthCheck probes the health endpoint of every endpoint at every interval, and stops sending requests
+// for ejectFor to the endpoints that do not answer with a 200. Use [Client.Close] to stop the health checks.
+func WithHealthCheck(interval time.Duration, ejectFor time.Duration) ClientOption {
+	return func(opts *clientOpts) {
+		opts.healthCheckInterval = interval
+		opts.ejectFor = ejectFor
+	}
+}
+
+// WithHealthPath sets the path of the health endpoint probed by [WithHealthCheck], "/health" by default.
+func WithHealthPath(path string) ClientOption {
+	return func(opts *clientOpts) {
+		opts.healthPath = path
+	}
+}
+
+// NewClient returns a client for the rpc endpoint at endpoint, e.g. "http://localhost:1234/rpc".
+func NewClient(endpoint string, options ...ClientOption) *Client {
+	return NewBalancedClient([]string{endpoint}, options...)
+}
+
+// NewBalancedClient returns a client spreading requests across the rpc endpoints of several replicas of a server.
+// It panics if endpoints is empty.
+func NewBalancedClient(endpoints []string, options ...ClientOption) *Client {
+	if len(endpoints) == 0 {
+		panic("at least one endpoint is required")
+	}
+	opts := defaultClientOpts()
+	for _, option := range options {
+		option(opts)
+	}
+	return &Client{
+		opts:     opts,
+		balancer: newBalancer(endpoints, opts),
+	}
+}
+
+// Close stops the health checks of the client.
+func (c *Client) Close() {
+	c.balancer.close()
+}
+
+// HTTPError is returned when the server answers with an http status and a body that is not a JSON-RPC response.
+type HTTPError struct {
+	StatusCode int
+	Body       []byte
+}
+
+func (h *HTTPError) Error() string {
+	return fmt.Sprintf("jsonrpc: unexpected http status %d", h.StatusCode)
+}
+
+// ErrMissingResponse is returned for the calls of a batch that the server did not answer.
+var ErrMissingResponse = errors.New("jsonrpc: missing response")
+
+// Call calls method with params and decodes its result into result, which must be a pointer or nil.
+func (c *Client) Call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	request := clientRequest{JSONRPC: "2.0", Method: method, ID: c.newID(), Params: params}
+	body, err := c.send(ctx, method, request, !c.opts.nonIdempotent[method], c.retryableResponse)
+	if err != nil {
+		return err
+	}
+	var response clientResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return fmt.Errorf("jsonrpc: failed to decode response: %w", err)
+	}
+	if response.Error == nil && !response.ID.Equal(request.ID) {
+		return fmt.Errorf("jsonrpc: response id %s does not match request id %s", response.ID, request.ID)
+	}
+	return response.decode(result)
+}
+
+// Notify calls method with params without waiting for a result. The server does not answer notifications,
+// so only transport errors are reported.
+func (c *Client) Notify(ctx context.Context, method string, params interface{}) error {
+	_, err := c.send(ctx, method, clientRequest{JSONRPC: "2.0", Method: method, Params: params}, false, nil)
+	return err
+}
+
+// BatchElem is a single call of a batch sent with [Client.BatchCall].
+type BatchElem struct {
+	Method string
+	Params interface{}
+	// Result is where the result of the call is decoded. It must be a pointer or nil.
+	Result interface{}
+	// Notification marks calls whose result is not expected.
+	Notification bool
+	// Error is set by BatchCall if the call failed or if the server did not answer it.
+	Error error
+}
+
+// BatchCall sends all elems in a single batch request. Responses are matched to their call by id, and the
+// result or error of each call is stored in the call itself. The returned error is only set when the batch
+// as a whole failed, e.g. because of a transport error or because the server rejected the batch.
+func (c *Client) BatchCall(ctx context.Context, elems []BatchElem) error {
+	requests := make([]clientRequest, len(elems))
+	calls := make(map[string]*BatchElem, len(elems))
+	retryable := true
+	for i := range elems {
+		requests[i] = clientRequest{JSONRPC: "2.0", Method: elems[i].Method, Params: elems[i].Params}
+		if !elems[i].Notification {
+			requests[i].ID = c.newID()
+			calls[string(requests[i].ID)] = &elems[i]
+		}
+		retryable = retryable && !elems[i].Notification && !c.opts.nonIdempotent[elems[i].Method]
+	}
+	var key string
+	if len(elems) > 0 {
+		key = elems[0].Method
+	}
+	body, err := c.send(ctx, key, requests, retryable, nil)
+	if err != nil {
+		return err
+	}
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '{' {
+		// The server rejected the whole batch with a single error, which it may do for notifications too.
+		var response clientResponse
+		if err := json.Unmarshal(body, &response); err != nil {
+			return fmt.Errorf("jsonrpc: failed to decode response: %w", err)
+		}
+		return response.decode(nil)
+	}
+	if len(calls) == 0 {
+		return nil
+	}
+	var responses []clientResponse
+	if err := json.Unmarshal(body, &responses); err != nil {
+		return fmt.Errorf("jsonrpc: failed to decode response: %w", err)
+	}
+	for _, response := range responses {
+		elem, ok := calls[string(response.ID)]
+		if !ok {
+			continue
+		}
+		delete(calls, string(response.ID))
+		elem.Error = response.decode(elem.Result)
+	}
+	for id, elem := range calls {
+		elem.Error = fmt.Errorf("%w for id %s", ErrMissingResponse, id)
+	}
+	return nil
+}
+
+// maxBatchSize returns the largest batch to send in a single request, or 0 if there is no limit.
+func (c *Client) maxBatchSize() int {
+	limit := c.opts.maxBatchSize
+	if learned := int(c.serverMaxBatchSize.Load()); learned > 0 && (limit <= 0 || learned < limit) {
+		limit = learned
+	}
+	return limit
+}
+
+func (c *Client) newID() ID {
+	return IntID(c.nextID.Add(1))
+}
+
+// send encodes payload and posts it to the endpoint picked for key. When retryable is true, failed attempts are
+// retried according to the retry policy, each of them possibly on another endpoint; retryResponse, if not nil,
+// reports whether a response received from the server should be retried as well.
+func (c *Client) send(ctx context.Context, key string, payload interface{}, retryable bool, retryResponse func(body []byte) bool) ([]byte, error) {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("jsonrpc: failed to encode request: %w", err)
+	}
+	attempts := 1
+	if retryable {
+		attempts = max(c.opts.retry.MaxAttempts, 1)
+	}
+	for attempt := 0; ; attempt++ {
+		body, err := c.post(ctx, key, b)
+		retry := (err != nil && retryableError(ctx, err)) || (err == nil && retryResponse != nil && retryResponse(body))
+		if !retry || attempt+1 >= attempts {
+			return body, err
+		}
+		if err := sleep(ctx, c.opts.retry.backoff(attempt)); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// retryableResponse reports whether the body of a single call response holds an error with a retryable code.
+func (c *Client) retryableResponse(body []byte) bool {
+	if len(c.opts.retry.RetryableCodes) == 0 {
+		return false
+	}
+	var response clientResponse
+	if err := json.Unmarshal(body, &response); err != nil || response.Error == nil {
+		return false
+	}
+	return c.opts.retry.retryableCode(response.Error.Code)
+}
+
+// post sends a single attempt of an encoded request to the endpoint picked for key and returns the body of the
+// http response.
+func (c *Client) post(ctx context.Context, key string, payload []byte) ([]byte, error) {
+	e, err := c.balancer.pick(key)
+	if err != nil {
+		return nil, err
+	}
+	if !e.breaker.allow() {
+		return nil, ErrCircuitOpen
+	}
+	e.inFlight.Add(1)
+	defer e.inFlight.Add(-1)
+	attemptCtx := ctx
+	if c.opts.timeout > 0 {
+		var cancel context.CancelFunc
+		attemptCtx, cancel = context.WithTimeout(ctx, c.opts.timeout)
+		defer cancel()
+	}
+	body, err := c.roundTrip(attemptCtx, e.url, payload)
+	if ctx.Err() != nil {
+		// The caller gave up on the request, which tells nothing about the health of the endpoint.
+		e.breaker.release()
+	} else {
+		e.breaker.record(err == nil)
+	}
+	return body, err
+}
+
+func (c *Client) roundTrip(ctx context.Context, endpoint string, payload []byte) ([]byte, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range c.opts.headers {
+		request.Header[key] = values
+	}
+	request.Header.Set("Content-Type", "application/json")
+	response, err := c.opts.httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = response.Body.Close()
+	}()
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	// The server answers failed calls with a 400 and a JSON-RPC error in the body.
+	if response.StatusCode != http.StatusOK && response.StatusCode != http.StatusNoContent &&
+		!(response.StatusCode == http.StatusBadRequest && json.Valid(body)) {
+		return nil, &HTTPError{StatusCode: response.StatusCode, Body: body}
+	}
+	return body, nil
+}
+
+// clientRequest is a request as sent by the client, with the params not encoded yet.
+type clientRequest struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	ID      ID          `json:"id,omitempty"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+// clientResponse is a response as received by the client, with the result left undecoded.
+type clientResponse struct {
+	JsonRPC string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+	ID      ID              `json:"id"`
+}
+
+// decode decodes the result of the response into result, or returns its error.
+func (c clientResponse) decode(result interface{}) error {
+	if c.Error != nil {
+		return errorFromRPCError(c.ID, *c.Error)
+	}
+	if result == nil || len(c.Result) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(c.Result, result); err != nil {
+		return fmt.Errorf("jsonrpc: failed to decode result: %w", err)
+	}
+	return nil
+}
+
+// errorFromRPCError converts an error object received from a server into the matching error type.
+func errorFromRPCError(id ID, rpcError RPCError) error {
+	switch rpcError.Code {
+	case -32700:
+		return ParseError{JsonRPC: "2.0", RpcError: rpcError, ID: id}
+	case -32600:
+		return InvalidRequestError{JsonRPC: "2.0", RpcError: rpcError, ID: id}
+	case -32601:
+		return MethodNotFoundError{JsonRPC: "2.0", RpcError: rpcError, ID: id}
+	case -32602:
+		return InvalidParamsError{JsonRPC: "2.0", RpcError: rpcError, ID: id}
+	case -32603:
+		return InternalError{JsonRPC: "2.0", RpcError: rpcError, ID: id}
+	}
+	return GeneralError{JsonRPC: "2.0", RpcError: rpcError, ID: id}
+}
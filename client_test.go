@@ -0,0 +1,232 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestClient(t *testing.T, options ...Option) (*Client, Server) {
+	t.Helper()
+	s := New(options...)
+	s.Register(NewTypedHandler("echo", echo))
+	s.Register(NewTypedHandler("fail", fail))
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+	return NewClient(server.URL + "/rpc"), s
+}
+
+func TestClientCall(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	var result string
+	require.NoError(t, client.Call(context.Background(), "echo", "hi", &result))
+	require.Equal(t, "hi", result)
+	require.NoError(t, client.Notify(context.Background(), "echo", "quiet"))
+
+	err := client.Call(context.Background(), "missing", nil, &result)
+	var methodNotFound MethodNotFoundError
+	require.True(t, errors.As(err, &methodNotFound))
+
+	err = client.Call(context.Background(), "echo", []int{1}, &result)
+	var invalidParams InvalidParamsError
+	require.True(t, errors.As(err, &invalidParams))
+
+	err = client.Call(context.Background(), "fail", "boom", &result)
+	var internal InternalError
+	require.True(t, errors.As(err, &internal))
+	require.Equal(t, "boom", internal.RpcError.Data["rationale"])
+}
+
+func TestClientBatchCall(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	var first, second string
+	batch := []BatchElem{
+		{Method: "echo", Params: "a", Result: &first},
+		{Method: "echo", Params: "quiet", Notification: true},
+		{Method: "echo", Params: "b", Result: &second},
+		{Method: "missing"},
+	}
+	require.NoError(t, client.BatchCall(context.Background(), batch))
+	require.Equal(t, "a", first)
+	require.Equal(t, "b", second)
+	require.NoError(t, batch[0].Error)
+	require.NoError(t, batch[2].Error)
+	var methodNotFound MethodNotFoundError
+	require.True(t, errors.As(batch[3].Error, &methodNotFound))
+
+	client, _ = newTestClient(t, WithMaxBatchSize(1))
+	err := client.BatchCall(context.Background(), []BatchElem{{Method: "echo", Params: "a"}, {Method: "echo", Params: "b"}})
+	var invalidRequest InvalidRequestError
+	require.True(t, errors.As(err, &invalidRequest))
+
+	err = client.BatchCall(context.Background(), []BatchElem{{Method: "echo", Params: "a", Notification: true}, {Method: "echo", Params: "b", Notification: true}})
+	require.True(t, errors.As(err, &invalidRequest))
+}
+
+func TestClientHTTPError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+	err := NewClient(server.URL).Call(context.Background(), "echo", "hi", nil)
+	var httpError *HTTPError
+	require.True(t, errors.As(err, &httpError))
+	require.Equal(t, http.StatusBadGateway, httpError.StatusCode)
+}
+
+func TestBatchBuilder(t *testing.T) {
+	client, _ := newTestClient(t, WithMaxBatchSize(2))
+
+	batch := client.NewBatch()
+	first := batch.Call("echo", "a")
+	batch.Notify("echo", "quiet")
+	second := batch.Call("echo", "b")
+	third := batch.Call("missing", nil)
+	fourth := batch.Call("echo", "c")
+	require.ErrorIs(t, first.Err(), ErrBatchNotSent)
+	require.NoError(t, batch.Send(context.Background()))
+	require.ErrorIs(t, batch.Send(context.Background()), ErrBatchAlreadySent)
+	require.Equal(t, 2, client.maxBatchSize())
+
+	var result string
+	require.NoError(t, first.Decode(&result))
+	require.Equal(t, "a", result)
+	require.NoError(t, second.Decode(&result))
+	require.Equal(t, "b", result)
+	var methodNotFound MethodNotFoundError
+	require.True(t, errors.As(third.Decode(&result), &methodNotFound))
+	require.NoError(t, fourth.Decode(&result))
+	require.Equal(t, "c", result)
+}
+
+func TestBatchBuilderNotifications(t *testing.T) {
+	client, s := newTestClient(t, WithMaxBatchSize(2))
+	var notified atomic.Int32
+	s.Register(NewTypedHandler("count", func(ctx context.Context, headers http.Header, id ID, params interface{}) (interface{}, error) {
+		notified.Add(1)
+		return nil, nil
+	}))
+
+	batch := client.NewBatch()
+	for i := 0; i < 5; i++ {
+		batch.Notify("count", nil)
+	}
+	require.NoError(t, batch.Send(context.Background()))
+	require.Equal(t, 2, client.maxBatchSize())
+	require.Equal(t, int32(5), notified.Load())
+}
+
+func TestBatchMissingResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		_, _ = writer.Write([]byte(`[{"jsonrpc":"2.0","result":"a","id":1}]`))
+	}))
+	defer server.Close()
+
+	batch := NewClient(server.URL, WithClientMaxBatchSize(10)).NewBatch()
+	first := batch.Call("echo", "a")
+	second := batch.Call("echo", "b")
+	require.NoError(t, batch.Send(context.Background()))
+	require.NoError(t, first.Err())
+	require.ErrorIs(t, second.Err(), ErrMissingResponse)
+}
+
+func TestClientRetries(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if attempts.Add(1)%3 != 0 {
+			writer.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = writer.Write([]byte(`{"jsonrpc":"2.0","result":"ok","id":1}`))
+	}))
+	defer server.Close()
+	policy := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, Jitter: 0.5}
+	client := NewClient(server.URL, WithRetryPolicy(policy), WithNonIdempotentMethods("transfer"))
+
+	var result string
+	require.NoError(t, client.Call(context.Background(), "get", nil, &result))
+	require.Equal(t, "ok", result)
+	require.Equal(t, int32(3), attempts.Load())
+
+	attempts.Store(0)
+	require.Error(t, client.Call(context.Background(), "transfer", nil, &result))
+	require.Equal(t, int32(1), attempts.Load())
+
+	attempts.Store(0)
+	require.Error(t, client.Notify(context.Background(), "get", nil))
+	require.Equal(t, int32(1), attempts.Load())
+
+	attempts.Store(0)
+	require.Error(t, client.BatchCall(context.Background(), []BatchElem{{Method: "get"}, {Method: "get", Notification: true}}))
+	require.Equal(t, int32(1), attempts.Load())
+}
+
+func TestClientRetryableCodes(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		attempts.Add(1)
+		writer.WriteHeader(http.StatusBadRequest)
+		_, _ = writer.Write(NewServerError(IntID(1), "Busy", -32001).JSONRPCBytes())
+	}))
+	defer server.Close()
+	client := NewClient(server.URL, WithRetryPolicy(RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond, RetryableCodes: []int{-32001}}))
+	var general GeneralError
+	require.True(t, errors.As(client.Call(context.Background(), "get", nil, nil), &general))
+	require.Equal(t, -32001, general.RpcError.Code)
+	require.Equal(t, int32(2), attempts.Load())
+}
+
+func TestClientTimeoutAndCircuitBreaker(t *testing.T) {
+	var attempts atomic.Int32
+	var healthy atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		attempts.Add(1)
+		if !healthy.Load() {
+			time.Sleep(50 * time.Millisecond)
+		}
+		var rpcRequest Request
+		_ = json.NewDecoder(request.Body).Decode(&rpcRequest)
+		_, _ = writer.Write(NewResponse(rpcRequest.ID, "ok").JSONRPCBytes())
+	}))
+	defer server.Close()
+	client := NewClient(server.URL, WithTimeout(10*time.Millisecond), WithCircuitBreaker(2, 50*time.Millisecond))
+
+	require.ErrorIs(t, client.Call(context.Background(), "get", nil, nil), context.DeadlineExceeded)
+	require.ErrorIs(t, client.Call(context.Background(), "get", nil, nil), context.DeadlineExceeded)
+	require.ErrorIs(t, client.Call(context.Background(), "get", nil, nil), ErrCircuitOpen)
+	require.Equal(t, int32(2), attempts.Load())
+
+	healthy.Store(true)
+	time.Sleep(60 * time.Millisecond)
+	require.NoError(t, client.Call(context.Background(), "get", nil, nil))
+	require.NoError(t, client.Call(context.Background(), "get", nil, nil))
+}
+
+func TestCircuitBreakerCancelledTrial(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+	}))
+	defer server.Close()
+	client := NewClient(server.URL, WithTimeout(10*time.Millisecond), WithCircuitBreaker(2, 20*time.Millisecond))
+
+	require.ErrorIs(t, client.Call(context.Background(), "get", nil, nil), context.DeadlineExceeded)
+	require.ErrorIs(t, client.Call(context.Background(), "get", nil, nil), context.DeadlineExceeded)
+	require.ErrorIs(t, client.Call(context.Background(), "get", nil, nil), ErrCircuitOpen)
+
+	// The trial request is cancelled by the caller: the breaker stays open and lets another trial through.
+	time.Sleep(30 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, client.Call(ctx, "get", nil, nil), context.DeadlineExceeded)
+	require.ErrorIs(t, client.Call(context.Background(), "get", nil, nil), context.DeadlineExceeded)
+	require.ErrorIs(t, client.Call(context.Background(), "get", nil, nil), ErrCircuitOpen)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"go/format"
+	"strings"
+	"text/template"
+)
+
+var codeTemplate = template.Must(template.New("code").Parse(`// Code generated by jsonrpc-gen. DO NOT EDIT.
+
+package {{.Service.Package}}
+
+import (
+{{- range .StdImports}}
+	{{.}}
+{{- end}}
+{{range .OtherImports}}
+	{{.}}
+{{- end}}
+)
+
+{{$service := .Service.Name}}{{$prefix := .Prefix}}
+// {{$service}}Client calls the {{$service}} methods of a JSON-RPC server.
+type {{$service}}Client struct {
+	client *jsonrpc.Client
+}
+
+var _ {{$service}} = (*{{$service}}Client)(nil)
+
+// New{{$service}}Client returns a {{$service}}Client sending its calls with client.
+func New{{$service}}Client(client *jsonrpc.Client) *{{$service}}Client {
+	return &{{$service}}Client{client: client}
+}
+{{range .Service.Methods}}
+func (c *{{$service}}Client) {{.Name}}(ctx context.Context, params {{.Params}}) ({{.Result}}, error) {
+	var result {{.Result}}
+	err := c.client.Call(ctx, "{{$prefix}}.{{.Name}}", params, &result)
+	return result, err
+}
+{{end}}
+// Register{{$service}} registers every method of impl with s.
+func Register{{$service}}(s jsonrpc.Server, impl {{$service}}) {
+{{- range .Service.Methods}}
+	s.Register(jsonrpc.NewTypedHandler("{{$prefix}}.{{.Name}}", func(ctx context.Context, headers http.Header, id jsonrpc.ID, params {{.Params}}) ({{.Result}}, error) {
+		return impl.{{.Name}}(ctx, params)
+	}))
+{{- end}}
+}
+`))
+
+// generate returns the formatted source of the client and server registration of s.
+func generate(s *service, prefix string) ([]byte, error) {
+	stdImports := []string{`"context"`, `"net/http"`}
+	otherImports := []string{`"github.com/calmdaysamuel/jsonrpc"`}
+	for _, spec := range s.Imports {
+		if importPath := spec[strings.Index(spec, `"`)+1:]; strings.Contains(strings.Split(importPath, "/")[0], ".") {
+			otherImports = append(otherImports, spec)
+		} else {
+			stdImports = append(stdImports, spec)
+		}
+	}
+	var b bytes.Buffer
+	err := codeTemplate.Execute(&b, struct {
+		Service      *service
+		Prefix       string
+		StdImports   []string
+		OtherImports []string
+	}{Service: s, Prefix: prefix, StdImports: stdImports, OtherImports: otherImports})
+	if err != nil {
+		return nil, err
+	}
+	return format.Source(b.Bytes())
+}
@@ -0,0 +1,63 @@
+// Command jsonrpc-gen generates a typed JSON-RPC client and server registration for Go interfaces.
+//
+// Every method of the interfaces must have the form
+//
+//	Method(ctx context.Context, params Params) (Result, error)
+//
+// For an interface Calculator, jsonrpc-gen emits a CalculatorClient implementing Calculator on top of a
+// *jsonrpc.Client, and a RegisterCalculator function registering an implementation of Calculator with a
+// jsonrpc.Server. Methods are named "Prefix.Method", the same way jsonrpc.Server.RegisterService names them,
+// with the interface name as the default prefix.
+//
+// Usage:
+//
+//	//go:generate go run github.com/calmdaysamuel/jsonrpc/cmd/jsonrpc-gen -type Calculator
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("jsonrpc-gen: ")
+	typeName := flag.String("type", "", "name of the interface to generate code for; required")
+	prefix := flag.String("prefix", "", "prefix of the rpc method names; defaults to the interface name")
+	output := flag.String("output", "", "output file name; defaults to <type>_jsonrpc.go in the package directory")
+	flag.Usage = func() {
+		_, _ = fmt.Fprintf(flag.CommandLine.Output(), "Usage: jsonrpc-gen -type Interface [-prefix prefix] [-output file] [directory]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if *typeName == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+	if *prefix == "" {
+		*prefix = *typeName
+	}
+	if *output == "" {
+		*output = filepath.Join(dir, strings.ToLower(*typeName)+"_jsonrpc.go")
+	}
+
+	service, err := parseService(dir, *typeName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	source, err := generate(service, *prefix)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*output, source, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
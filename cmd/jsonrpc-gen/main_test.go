@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeSource(t *testing.T, source string) string {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "service.go"), []byte(source), 0o644))
+	return dir
+}
+
+func TestGenerate(t *testing.T) {
+	dir := writeSource(t, `package users
+
+import (
+	"context"
+	"time"
+
+	pb "example.com/users/proto"
+)
+
+type Users interface {
+	Get(ctx context.Context, params pb.GetRequest) (*pb.User, error)
+	LastSeen(ctx context.Context, id string) (time.Time, error)
+}
+`)
+	s, err := parseService(dir, "Users")
+	require.NoError(t, err)
+	require.Equal(t, []method{
+		{Name: "Get", Params: "pb.GetRequest", Result: "*pb.User"},
+		{Name: "LastSeen", Params: "string", Result: "time.Time"},
+	}, s.Methods)
+
+	source, err := generate(s, "users")
+	require.NoError(t, err)
+	code := string(source)
+	require.Contains(t, code, "package users")
+	require.Contains(t, code, "import (\n\t\"context\"\n\t\"net/http\"\n\t\"time\"\n\n\tpb \"example.com/users/proto\"\n\t\"github.com/calmdaysamuel/jsonrpc\"\n)")
+	require.Contains(t, code, `err := c.client.Call(ctx, "users.Get", params, &result)`)
+	require.Contains(t, code, `s.Register(jsonrpc.NewTypedHandler("users.LastSeen", func(ctx context.Context, headers http.Header, id jsonrpc.ID, params string) (time.Time, error) {`)
+}
+
+func TestParseServiceErrors(t *testing.T) {
+	dir := writeSource(t, `package users
+
+type Users interface {
+	Get(id string) (string, error)
+}
+`)
+	_, err := parseService(dir, "Users")
+	require.ErrorContains(t, err, "method Get must have the form")
+
+	_, err = parseService(dir, "Missing")
+	require.ErrorContains(t, err, "interface Missing not found")
+}
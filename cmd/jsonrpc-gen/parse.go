@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// service describes an interface to generate code for.
+type service struct {
+	Package string
+	Name    string
+	Methods []method
+	// Imports are the import specs needed by the parameter and result types, e.g. `"time"` or `pb "example.com/pb"`.
+	Imports []string
+}
+
+type method struct {
+	Name   string
+	Params string
+	Result string
+}
+
+// parseService finds the interface typeName among the Go files of dir.
+func parseService(dir string, typeName string) (*service, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	for _, name := range files {
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		src, err := os.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		file, err := parser.ParseFile(fset, name, src, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		if iface := findInterface(file, typeName); iface != nil {
+			return newService(fset, file, typeName, iface)
+		}
+	}
+	return nil, fmt.Errorf("interface %s not found in %s", typeName, dir)
+}
+
+func findInterface(file *ast.File, typeName string) *ast.InterfaceType {
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			typeSpec := spec.(*ast.TypeSpec)
+			if iface, ok := typeSpec.Type.(*ast.InterfaceType); ok && typeSpec.Name.Name == typeName {
+				return iface
+			}
+		}
+	}
+	return nil
+}
+
+func newService(fset *token.FileSet, file *ast.File, typeName string, iface *ast.InterfaceType) (*service, error) {
+	s := &service{Package: file.Name.Name, Name: typeName}
+	packages := map[string]bool{}
+	for _, field := range iface.Methods.List {
+		funcType, ok := field.Type.(*ast.FuncType)
+		if !ok {
+			return nil, fmt.Errorf("%s: embedded interfaces are not supported", fset.Position(field.Pos()))
+		}
+		name := field.Names[0].Name
+		params, results := flatten(funcType.Params), flatten(funcType.Results)
+		if len(params) != 2 || exprString(fset, params[0]) != "context.Context" ||
+			len(results) != 2 || exprString(fset, results[1]) != "error" {
+			return nil, fmt.Errorf("%s: method %s must have the form %s(ctx context.Context, params Params) (Result, error)",
+				fset.Position(field.Pos()), name, name)
+		}
+		s.Methods = append(s.Methods, method{
+			Name:   name,
+			Params: exprString(fset, params[1]),
+			Result: exprString(fset, results[0]),
+		})
+		collectPackages(params[1], packages)
+		collectPackages(results[0], packages)
+	}
+	for _, spec := range file.Imports {
+		importPath, _ := strconv.Unquote(spec.Path.Value)
+		name := path.Base(importPath)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		if !packages[name] {
+			continue
+		}
+		if spec.Name != nil {
+			s.Imports = append(s.Imports, spec.Name.Name+" "+spec.Path.Value)
+		} else {
+			s.Imports = append(s.Imports, spec.Path.Value)
+		}
+	}
+	return s, nil
+}
+
+// flatten returns the type of every parameter of fields, repeating the type of grouped parameters like (a, b int).
+func flatten(fields *ast.FieldList) []ast.Expr {
+	if fields == nil {
+		return nil
+	}
+	var types []ast.Expr
+	for _, field := range fields.List {
+		for range max(len(field.Names), 1) {
+			types = append(types, field.Type)
+		}
+	}
+	return types
+}
+
+// collectPackages adds the name of every package referenced by expr to packages.
+func collectPackages(expr ast.Expr, packages map[string]bool) {
+	ast.Inspect(expr, func(node ast.Node) bool {
+		if selector, ok := node.(*ast.SelectorExpr); ok {
+			if ident, ok := selector.X.(*ast.Ident); ok {
+				packages[ident.Name] = true
+			}
+		}
+		return true
+	})
+}
+
+func exprString(fset *token.FileSet, expr ast.Expr) string {
+	var b bytes.Buffer
+	_ = printer.Fprint(&b, fset, expr)
+	return b.String()
+}
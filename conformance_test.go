@@ -0,0 +1,216 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// conformanceCases are the examples of section 7 of the JSON-RPC 2.0 specification.
+// An empty response means that the server must not answer.
+var conformanceCases = []struct {
+	name     string
+	request  string
+	response string
+	status   int
+}{
+	{
+		name:     "positional params",
+		request:  `{"jsonrpc": "2.0", "method": "subtract", "params": [42, 23], "id": 1}`,
+		response: `{"jsonrpc": "2.0", "result": 19, "id": 1}`,
+		status:   http.StatusOK,
+	},
+	{
+		name:     "positional params reversed",
+		request:  `{"jsonrpc": "2.0", "method": "subtract", "params": [23, 42], "id": 2}`,
+		response: `{"jsonrpc": "2.0", "result": -19, "id": 2}`,
+		status:   http.StatusOK,
+	},
+	{
+		name:     "named params",
+		request:  `{"jsonrpc": "2.0", "method": "subtract", "params": {"subtrahend": 23, "minuend": 42}, "id": 3}`,
+		response: `{"jsonrpc": "2.0", "result": 19, "id": 3}`,
+		status:   http.StatusOK,
+	},
+	{
+		name:     "named params reordered",
+		request:  `{"jsonrpc": "2.0", "method": "subtract", "params": {"minuend": 42, "subtrahend": 23}, "id": 4}`,
+		response: `{"jsonrpc": "2.0", "result": 19, "id": 4}`,
+		status:   http.StatusOK,
+	},
+	{
+		name:    "notification",
+		request: `{"jsonrpc": "2.0", "method": "update", "params": [1,2,3,4,5]}`,
+		status:  http.StatusNoContent,
+	},
+	{
+		name:    "notification of a missing method",
+		request: `{"jsonrpc": "2.0", "method": "foobar"}`,
+		status:  http.StatusNoContent,
+	},
+	{
+		name:     "missing method",
+		request:  `{"jsonrpc": "2.0", "method": "foobar", "id": "1"}`,
+		response: `{"jsonrpc": "2.0", "error": {"code": -32601, "message": "Method not found"}, "id": "1"}`,
+		status:   http.StatusBadRequest,
+	},
+	{
+		name:     "invalid JSON",
+		request:  `{"jsonrpc": "2.0", "method": "foobar, "params": "bar", "baz]`,
+		response: `{"jsonrpc": "2.0", "error": {"code": -32700, "message": "Parse error"}, "id": null}`,
+		status:   http.StatusBadRequest,
+	},
+	{
+		name:     "invalid request object",
+		request:  `{"jsonrpc": "2.0", "method": 1, "params": "bar"}`,
+		response: `{"jsonrpc": "2.0", "error": {"code": -32600, "message": "Invalid Request"}, "id": null}`,
+		status:   http.StatusBadRequest,
+	},
+	{
+		name: "batch with invalid JSON",
+		request: `[
+			{"jsonrpc": "2.0", "method": "sum", "params": [1,2,4], "id": "1"},
+			{"jsonrpc": "2.0", "method"
+		]`,
+		response: `{"jsonrpc": "2.0", "error": {"code": -32700, "message": "Parse error"}, "id": null}`,
+		status:   http.StatusBadRequest,
+	},
+	{
+		name:     "empty batch",
+		request:  `[]`,
+		response: `{"jsonrpc": "2.0", "error": {"code": -32600, "message": "Invalid Request"}, "id": null}`,
+		status:   http.StatusBadRequest,
+	},
+	{
+		name:     "invalid batch entry",
+		request:  `[1]`,
+		response: `[{"jsonrpc": "2.0", "error": {"code": -32600, "message": "Invalid Request"}, "id": null}]`,
+		status:   http.StatusOK,
+	},
+	{
+		name:    "invalid batch entries",
+		request: `[1,2,3]`,
+		response: `[
+			{"jsonrpc": "2.0", "error": {"code": -32600, "message": "Invalid Request"}, "id": null},
+			{"jsonrpc": "2.0", "error": {"code": -32600, "message": "Invalid Request"}, "id": null},
+			{"jsonrpc": "2.0", "error": {"code": -32600, "message": "Invalid Request"}, "id": null}
+		]`,
+		status: http.StatusOK,
+	},
+	{
+		name: "batch",
+		request: `[
+			{"jsonrpc": "2.0", "method": "sum", "params": [1,2,4], "id": "1"},
+			{"jsonrpc": "2.0", "method": "notify_hello", "params": [7]},
+			{"jsonrpc": "2.0", "method": "subtract", "params": [42,23], "id": "2"},
+			{"foo": "boo"},
+			{"jsonrpc": "2.0", "method": "foo.get", "params": {"name": "myself"}, "id": "5"},
+			{"jsonrpc": "2.0", "method": "get_data", "id": "9"}
+		]`,
+		response: `[
+			{"jsonrpc": "2.0", "result": 7, "id": "1"},
+			{"jsonrpc": "2.0", "result": 19, "id": "2"},
+			{"jsonrpc": "2.0", "error": {"code": -32600, "message": "Invalid Request"}, "id": null},
+			{"jsonrpc": "2.0", "error": {"code": -32601, "message": "Method not found"}, "id": "5"},
+			{"jsonrpc": "2.0", "result": ["hello", 5], "id": "9"}
+		]`,
+		status: http.StatusOK,
+	},
+	{
+		name: "batch of notifications",
+		request: `[
+			{"jsonrpc": "2.0", "method": "notify_sum", "params": [1,2,4]},
+			{"jsonrpc": "2.0", "method": "notify_hello", "params": [7]}
+		]`,
+		status: http.StatusNoContent,
+	},
+}
+
+func conformanceServer() Server {
+	s := New()
+	s.Register(NewTypedHandler("subtract", subtract, WithParamNames("minuend", "subtrahend")))
+	s.Register(NewTypedHandler("sum", func(ctx context.Context, headers http.Header, id ID, params []int) (int, error) {
+		total := 0
+		for _, v := range params {
+			total += v
+		}
+		return total, nil
+	}))
+	ignore := func(ctx context.Context, headers http.Header, id ID, params json.RawMessage) (interface{}, error) {
+		return nil, nil
+	}
+	s.Register(NewTypedHandler("update", ignore))
+	s.Register(NewTypedHandler("notify_hello", ignore))
+	s.Register(NewTypedHandler("notify_sum", ignore))
+	s.Register(NewTypedHandler("get_data", func(ctx context.Context, headers http.Header, id ID, params json.RawMessage) ([]interface{}, error) {
+		return []interface{}{"hello", 5}, nil
+	}))
+	return s
+}
+
+// requireConformantResponse compares a response to the one of the specification. The data of errors is left out as
+// it is implementation defined, and so is the order of the responses of a batch.
+func requireConformantResponse(t *testing.T, expected string, actual []byte) {
+	t.Helper()
+	if expected == "" {
+		require.Empty(t, actual)
+		return
+	}
+	var response interface{}
+	require.NoError(t, json.Unmarshal(actual, &response), string(actual))
+	normalize := func(response interface{}) string {
+		if object, ok := response.(map[string]interface{}); ok {
+			if rpcError, ok := object["error"].(map[string]interface{}); ok {
+				delete(rpcError, "data")
+			}
+		}
+		b, err := json.Marshal(response)
+		require.NoError(t, err)
+		return string(b)
+	}
+	if !strings.HasPrefix(strings.TrimSpace(expected), "[") {
+		require.JSONEq(t, expected, normalize(response))
+		return
+	}
+	var expectedResponses []interface{}
+	require.NoError(t, json.Unmarshal([]byte(expected), &expectedResponses))
+	responses, ok := response.([]interface{})
+	require.True(t, ok, "expected a batch response but got %s", actual)
+	var want, got []string
+	for _, r := range expectedResponses {
+		want = append(want, normalize(r))
+	}
+	for _, r := range responses {
+		got = append(got, normalize(r))
+	}
+	require.ElementsMatch(t, want, got)
+}
+
+func TestConformanceHTTP(t *testing.T) {
+	s := conformanceServer()
+	for _, test := range conformanceCases {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := serve(t, s, test.request)
+			require.Equal(t, test.status, recorder.Code)
+			requireConformantResponse(t, test.response, recorder.Body.Bytes())
+		})
+	}
+}
+
+func TestConformanceStream(t *testing.T) {
+	s := conformanceServer()
+	for _, test := range conformanceCases {
+		t.Run(test.name, func(t *testing.T) {
+			// The line codec needs every message on a single line.
+			request := strings.Join(strings.Fields(test.request), " ")
+			output := &bytes.Buffer{}
+			require.NoError(t, s.ServeStream(context.Background(), NewLineCodec(strings.NewReader(request+"\n"), output)))
+			requireConformantResponse(t, test.response, bytes.TrimSpace(output.Bytes()))
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type benchmarkParams struct {
+	Name   string            `json:"name"`
+	Values []int             `json:"values"`
+	Labels map[string]string `json:"labels"`
+}
+
+func benchmarkServer(b *testing.B) Server {
+	b.Helper()
+	logger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	b.Cleanup(func() { slog.SetDefault(logger) })
+
+	s := New(WithMaxBatchSize(100))
+	s.Register(NewTypedHandler("typed", func(ctx context.Context, headers http.Header, id ID, params benchmarkParams) (int, error) {
+		return len(params.Values), nil
+	}))
+	s.Register(NewTypedHandler("raw", func(ctx context.Context, headers http.Header, id ID, params json.RawMessage) (int, error) {
+		return len(params), nil
+	}))
+	return s
+}
+
+func benchmarkRequest(method string, id int, values int) string {
+	params := benchmarkParams{Name: "benchmark", Labels: map[string]string{"a": "b", "c": "d"}}
+	for i := 0; i < values; i++ {
+		params.Values = append(params.Values, i)
+	}
+	b, _ := json.Marshal(params)
+	return `{"jsonrpc":"2.0","method":"` + method + `","id":` + strconv.Itoa(id) + `,"params":` + string(b) + `}`
+}
+
+func runBenchmark(b *testing.B, s Server, body string) {
+	b.Helper()
+	b.SetBytes(int64(len(body)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		recorder := httptest.NewRecorder()
+		s.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(body)))
+		if recorder.Code != http.StatusOK {
+			b.Fatalf("unexpected status %d: %s", recorder.Code, recorder.Body.String())
+		}
+	}
+}
+
+func BenchmarkSingleRequest(b *testing.B) {
+	runBenchmark(b, benchmarkServer(b), benchmarkRequest("typed", 1, 10))
+}
+
+func BenchmarkSingleRequestLargeParams(b *testing.B) {
+	runBenchmark(b, benchmarkServer(b), benchmarkRequest("typed", 1, 10000))
+}
+
+func BenchmarkSingleRequestRawParams(b *testing.B) {
+	runBenchmark(b, benchmarkServer(b), benchmarkRequest("raw", 1, 10000))
+}
+
+func BenchmarkBatchRequest(b *testing.B) {
+	requests := make([]string, 0, 50)
+	for i := 0; i < 50; i++ {
+		requests = append(requests, benchmarkRequest("typed", i, 10))
+	}
+	runBenchmark(b, benchmarkServer(b), "["+strings.Join(requests, ",")+"]")
+}
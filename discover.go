@@ -0,0 +1,337 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DiscoverMethodName is the reserved method returning the OpenRPC document of the server.
+const DiscoverMethodName = "rpc.discover"
+
+// OpenRPCVersion is the version of the OpenRPC specification the discovery document follows.
+const OpenRPCVersion = "1.3.2"
+
+// OpenRPCDocument describes the methods served by a server, following https://spec.open-rpc.org.
+type OpenRPCDocument struct {
+	OpenRPC string          `json:"openrpc"`
+	Info    OpenRPCInfo     `json:"info"`
+	Methods []OpenRPCMethod `json:"methods"`
+}
+
+type OpenRPCInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type OpenRPCMethod struct {
+	Name string `json:"name"`
+	// ParamStructure is "by-name" when the parameters are the fields of an object, "either" when they can also be
+	// sent by position, and empty otherwise.
+	ParamStructure string                     `json:"paramStructure,omitempty"`
+	Params         []OpenRPCContentDescriptor `json:"params"`
+	Result         *OpenRPCContentDescriptor  `json:"result,omitempty"`
+	Errors         []OpenRPCError             `json:"errors,omitempty"`
+}
+
+// OpenRPCContentDescriptor describes a parameter or the result of a method with a JSON schema.
+// The schema is empty, and so accepts any value, when the handler does not make its types known.
+type OpenRPCContentDescriptor struct {
+	Name     string                 `json:"name"`
+	Required bool                   `json:"required,omitempty"`
+	Schema   map[string]interface{} `json:"schema"`
+}
+
+type OpenRPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// typedMethod is implemented by the handlers that know the go types of their parameters and result,
+// which are described in the discovery document.
+type typedMethod interface {
+	paramsType() reflect.Type
+	resultType() reflect.Type
+	// declaredParams returns the params declared with WithParamNames, if any.
+	declaredParams() []namedParam
+}
+
+func (t *TypedHandler[P, R]) paramsType() reflect.Type {
+	return reflect.TypeFor[P]()
+}
+
+func (t *TypedHandler[P, R]) resultType() reflect.Type {
+	return reflect.TypeFor[R]()
+}
+
+func (t *TypedHandler[P, R]) declaredParams() []namedParam {
+	return t.params
+}
+
+func (s *serviceMethod) declaredParams() []namedParam {
+	return nil
+}
+
+func (s *serviceMethod) paramsType() reflect.Type {
+	return s.argType
+}
+
+func (s *serviceMethod) resultType() reflect.Type {
+	return s.fn.Type().Out(0)
+}
+
+// methodErrors are the errors every method can return once the request has been routed to it.
+var methodErrors = []OpenRPCError{
+	{Code: -32602, Message: "Invalid params"},
+	{Code: -32603, Message: "Internal error"},
+}
+
+// discoveryDocument returns the OpenRPC document of the registered methods, sorted by name.
+func (j *jsonRPCServer) discoveryDocument() OpenRPCDocument {
+	document := OpenRPCDocument{
+		OpenRPC: OpenRPCVersion,
+		Info:    OpenRPCInfo{Title: j.opts.openRPCTitle, Version: j.opts.openRPCVersion},
+		Methods: []OpenRPCMethod{},
+	}
+	for _, handler := range j.methods {
+		document.Methods = append(document.Methods, describeMethod(handler, j.opts.paramsSchemas[handler.MethodName()]))
+	}
+	slices.SortFunc(document.Methods, func(a, b OpenRPCMethod) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return document
+}
+
+// describeMethod returns the description of a method. The params schema attached with WithParamsSchema, if any,
+// takes precedence over the schema derived from the go types of the handler.
+//
+// OpenRPC describes params as the members of an object or array, so only methods taking an object are described
+// with params: a struct, or an attached schema of type object listing its properties. Other methods, including
+// the ones taking a single string or number and untyped handlers, are described with no params at all.
+func describeMethod(handler RPCHandler, paramsSchema json.RawMessage) OpenRPCMethod {
+	method := OpenRPCMethod{
+		Name:   handler.MethodName(),
+		Params: []OpenRPCContentDescriptor{},
+		Errors: methodErrors,
+	}
+	typed, ok := handler.(typedMethod)
+	method.Result = &OpenRPCContentDescriptor{Name: "result", Schema: map[string]interface{}{}}
+	if ok {
+		method.Result.Schema = jsonSchema(typed.resultType(), map[reflect.Type]bool{})
+	}
+	var attached map[string]interface{}
+	if json.Unmarshal(paramsSchema, &attached) == nil && attached != nil {
+		describeSchemaParams(&method, attached, paramsSchema)
+		return method
+	}
+	if !ok {
+		return method
+	}
+	paramsType := typed.paramsType()
+	for paramsType.Kind() == reflect.Pointer {
+		paramsType = paramsType.Elem()
+	}
+	if paramsType.Kind() != reflect.Struct || isOpaqueType(paramsType) {
+		return method
+	}
+	fields := jsonFields(paramsType)
+	method.ParamStructure = "by-name"
+	if declared := typed.declaredParams(); declared != nil {
+		method.ParamStructure = "either"
+		named := make([]jsonField, 0, len(declared))
+		for _, param := range declared {
+			i := slices.IndexFunc(fields, func(field jsonField) bool { return field.name == param.name })
+			if i < 0 {
+				// parseParamNames refuses the names that are not fields, this only guards against a mismatch.
+				continue
+			}
+			named = append(named, jsonField{name: param.name, typ: fields[i].typ, required: !param.optional})
+		}
+		fields = named
+	}
+	for _, field := range fields {
+		method.Params = append(method.Params, OpenRPCContentDescriptor{
+			Name:     field.name,
+			Required: field.required,
+			Schema:   jsonSchema(field.typ, map[reflect.Type]bool{}),
+		})
+	}
+	return method
+}
+
+// describeSchemaParams describes the params of method with the properties of the attached schema, sorted by name,
+// if it is an object schema. Schemas using $ref are not described: their references are relative to the whole
+// schema and would not resolve from the schema of a single property.
+func describeSchemaParams(method *OpenRPCMethod, attached map[string]interface{}, raw json.RawMessage) {
+	properties, ok := attached["properties"].(map[string]interface{})
+	if attached["type"] != "object" || !ok || bytes.Contains(raw, []byte(`"$ref"`)) {
+		return
+	}
+	required, _ := attached["required"].([]interface{})
+	names := slices.Sorted(maps.Keys(properties))
+	for _, name := range names {
+		schema, ok := properties[name].(map[string]interface{})
+		if !ok {
+			// A boolean schema.
+			schema = map[string]interface{}{}
+			if properties[name] == false {
+				schema["not"] = map[string]interface{}{}
+			}
+		}
+		method.Params = append(method.Params, OpenRPCContentDescriptor{
+			Name:     name,
+			Required: slices.Contains(required, interface{}(name)),
+			Schema:   schema,
+		})
+	}
+	method.ParamStructure = "by-name"
+}
+
+type jsonField struct {
+	name     string
+	typ      reflect.Type
+	required bool
+}
+
+// jsonFields returns the fields of a struct as encoding/json sees them, including the fields of embedded structs.
+// Fields without the omitempty option are required.
+func jsonFields(t reflect.Type) []jsonField {
+	var fields []jsonField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		fieldType := field.Type
+		if field.Anonymous && name == "" {
+			for fieldType.Kind() == reflect.Pointer {
+				fieldType = fieldType.Elem()
+			}
+			if fieldType.Kind() == reflect.Struct {
+				fields = append(fields, jsonFields(fieldType)...)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields = append(fields, jsonField{
+			name:     name,
+			typ:      fieldType,
+			required: !slices.Contains(strings.Split(options, ","), "omitempty"),
+		})
+	}
+	return fields
+}
+
+var (
+	timeType          = reflect.TypeFor[time.Time]()
+	rawMessageType    = reflect.TypeFor[json.RawMessage]()
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+func implements(t reflect.Type, iface reflect.Type) bool {
+	return t.Implements(iface) || reflect.PointerTo(t).Implements(iface)
+}
+
+// isOpaqueType reports whether values of t are encoded by their own marshaling methods,
+// in which case their schema cannot be derived from their fields.
+func isOpaqueType(t reflect.Type) bool {
+	return implements(t, jsonMarshalerType) || implements(t, textMarshalerType)
+}
+
+// jsonSchema returns the JSON schema of the values of t once encoded by encoding/json.
+// Recursive types are only described up to their first repetition.
+func jsonSchema(t reflect.Type, seen map[reflect.Type]bool) map[string]interface{} {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t == rawMessageType || implements(t, jsonMarshalerType):
+		return map[string]interface{}{}
+	case implements(t, textMarshalerType):
+		return map[string]interface{}{"type": "string"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem() == reflect.TypeFor[byte]() {
+			return map[string]interface{}{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]interface{}{"type": "array", "items": jsonSchema(t.Elem(), seen)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": jsonSchema(t.Elem(), seen)}
+	case reflect.Struct:
+		if seen[t] {
+			return map[string]interface{}{"type": "object"}
+		}
+		seen[t] = true
+		defer delete(seen, t)
+		properties := map[string]interface{}{}
+		required := []string{}
+		for _, field := range jsonFields(t) {
+			properties[field.name] = jsonSchema(field.typ, seen)
+			if field.required {
+				required = append(required, field.name)
+			}
+		}
+		schema := map[string]interface{}{"type": "object", "properties": properties}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+	}
+	return map[string]interface{}{}
+}
+
+// discoverHandler serves the reserved rpc.discover method.
+type discoverHandler struct {
+	server *jsonRPCServer
+}
+
+func (d *discoverHandler) MethodName() string {
+	return DiscoverMethodName
+}
+
+func (d *discoverHandler) Execute(ctx context.Context, headers http.Header, id ID, params interface{}) (interface{}, error) {
+	return d.server.discoveryDocument(), nil
+}
+
+func (d *discoverHandler) ParametersValid(ctx context.Context, params interface{}) ([]Detail, bool) {
+	return nil, true
+}
+
+// serveDiscoveryDocument answers a GET request on the rpc endpoint with the OpenRPC document of the server.
+func (j *jsonRPCServer) serveDiscoveryDocument(writer http.ResponseWriter) {
+	b, err := json.Marshal(j.discoveryDocument())
+	if err != nil {
+		writer.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_, _ = writer.Write(b)
+}
@@ -0,0 +1,117 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type untypedHandler struct{}
+
+func (untypedHandler) MethodName() string {
+	return "untyped"
+}
+
+func (untypedHandler) Execute(ctx context.Context, headers http.Header, id ID, params interface{}) (interface{}, error) {
+	return params, nil
+}
+
+func (untypedHandler) ParametersValid(ctx context.Context, params interface{}) ([]Detail, bool) {
+	return nil, true
+}
+
+type event struct {
+	Name     string    `json:"name"`
+	At       time.Time `json:"at"`
+	Tags     []string  `json:"tags,omitempty"`
+	Payload  []byte    `json:"payload,omitempty"`
+	Children []event   `json:"children,omitempty"`
+}
+
+func TestDiscover(t *testing.T) {
+	s := New(WithOpenRPCInfo("calculator", "2.1.0"),
+		WithParamsSchema("greet", json.RawMessage(`{"type":"object","required":["name"],"properties":{"name":{"type":"string"},"polite":true}}`)),
+		WithParamsSchema("shout", json.RawMessage(`{"type":"string"}`)))
+	s.Register(NewTypedHandler("echo", echo))
+	s.Register(NewTypedHandler("greet", func(ctx context.Context, headers http.Header, id ID, params map[string]interface{}) (string, error) {
+		return "hello", nil
+	}))
+	s.Register(NewTypedHandler("shout", echo))
+	s.Register(NewTypedHandler("sum", sum))
+	s.Register(untypedHandler{})
+	s.RegisterService("arith", arith{})
+	s.Register(NewTypedHandler("event", func(ctx context.Context, headers http.Header, id ID, params event) (*event, error) {
+		return &params, nil
+	}))
+
+	recorder := serve(t, s, `{"jsonrpc":"2.0","method":"rpc.discover","id":1}`)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var response struct {
+		Result OpenRPCDocument `json:"result"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	document := response.Result
+	require.Equal(t, OpenRPCVersion, document.OpenRPC)
+	require.Equal(t, OpenRPCInfo{Title: "calculator", Version: "2.1.0"}, document.Info)
+
+	var names []string
+	methods := map[string]OpenRPCMethod{}
+	for _, method := range document.Methods {
+		names = append(names, method.Name)
+		methods[method.Name] = method
+	}
+	require.Equal(t, []string{"arith.Add", "arith.Divide", "echo", "event", "greet", "shout", "sum", "untyped"}, names)
+
+	// A string cannot be sent as the by-name or by-position params OpenRPC describes, so echo is described without params.
+	require.Equal(t, OpenRPCMethod{
+		Name:   "echo",
+		Params: []OpenRPCContentDescriptor{},
+		Result: &OpenRPCContentDescriptor{Name: "result", Schema: map[string]interface{}{"type": "string"}},
+		Errors: methodErrors,
+	}, methods["echo"])
+
+	require.Equal(t, "by-name", methods["arith.Add"].ParamStructure)
+	require.Equal(t, []OpenRPCContentDescriptor{
+		{Name: "a", Required: true, Schema: map[string]interface{}{"type": "integer"}},
+		{Name: "b", Required: true, Schema: map[string]interface{}{"type": "integer"}},
+	}, methods["arith.Add"].Params)
+
+	require.Equal(t, "by-name", methods["greet"].ParamStructure)
+	require.Equal(t, []OpenRPCContentDescriptor{
+		{Name: "name", Required: true, Schema: map[string]interface{}{"type": "string"}},
+		{Name: "polite", Schema: map[string]interface{}{}},
+	}, methods["greet"].Params)
+	require.Empty(t, methods["shout"].ParamStructure)
+	require.Empty(t, methods["shout"].Params)
+	require.Empty(t, methods["untyped"].ParamStructure)
+	require.Empty(t, methods["untyped"].Params)
+
+	b, err := json.Marshal(methods["event"].Result.Schema)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"type": "object",
+		"required": ["name", "at"],
+		"properties": {
+			"name": {"type": "string"},
+			"at": {"type": "string", "format": "date-time"},
+			"tags": {"type": "array", "items": {"type": "string"}},
+			"payload": {"type": "string", "contentEncoding": "base64"},
+			"children": {"type": "array", "items": {"type": "object"}}
+		}
+	}`, string(b))
+
+	recorder = httptest.NewRecorder()
+	s.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/rpc", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	var fromGet OpenRPCDocument
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &fromGet))
+	require.Equal(t, document, fromGet)
+
+	require.Panics(t, func() { s.Register(NewTypedHandler(DiscoverMethodName, echo)) })
+}
@@ -9,7 +9,11 @@ type ToJSONRPCBytes interface {
 type ParseError struct {
 	JsonRPC  string   `json:"jsonrpc"`
 	RpcError RPCError `json:"error"`
-	ID       *string  `json:"id"`
+	ID       ID       `json:"id"`
+}
+
+func (p ParseError) Error() string {
+	return p.RpcError.Message
 }
 
 func NewParseError(details ...Detail) ParseError {
@@ -5,13 +5,13 @@ import "encoding/json"
 type GeneralError struct {
 	JsonRPC  string   `json:"jsonrpc"`
 	RpcError RPCError `json:"error"`
-	ID       *string  `json:"id"`
+	ID       ID       `json:"id"`
 }
 
 func (g GeneralError) Error() string {
 	return g.RpcError.Message
 }
-func FromStandardError(id *string, err error) GeneralError {
+func FromStandardError(id ID, err error) GeneralError {
 	return GeneralError{
 		JsonRPC:  "2.0",
 		RpcError: RPCError{Code: 0, Message: err.Error()},
@@ -19,7 +19,7 @@ func FromStandardError(id *string, err error) GeneralError {
 	}
 }
 
-func NewGeneralError(id *string, message string, code int, details ...Detail) GeneralError {
+func NewGeneralError(id ID, message string, code int, details ...Detail) GeneralError {
 	detailsMap := map[string]interface{}{}
 	for _, d := range details {
 		detailsMap[d.Key()] = d.Value()
@@ -31,6 +31,27 @@ func NewGeneralError(id *string, message string, code int, details ...Detail) Ge
 	}
 }
 
+const (
+	// MinServerErrorCode is the lowest code of the range reserved for implementation-defined server errors.
+	MinServerErrorCode = -32099
+	// MaxServerErrorCode is the highest code of the range reserved for implementation-defined server errors.
+	MaxServerErrorCode = -32000
+)
+
+// IsServerErrorCode returns true if code is in the range reserved for implementation-defined server errors.
+func IsServerErrorCode(code int) bool {
+	return code >= MinServerErrorCode && code <= MaxServerErrorCode
+}
+
+// NewServerError returns an implementation-defined server error.
+// It panics if code is outside the reserved range -32099..-32000.
+func NewServerError(id ID, message string, code int, details ...Detail) GeneralError {
+	if !IsServerErrorCode(code) {
+		panic("server error code must be between -32099 and -32000")
+	}
+	return NewGeneralError(id, message, code, details...)
+}
+
 func (g GeneralError) JSONRPCBytes() []byte {
 	b, err := json.Marshal(g)
 	if err != nil {
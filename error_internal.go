@@ -0,0 +1,33 @@
+package jsonrpc
+
+import "encoding/json"
+
+type InternalError struct {
+	JsonRPC  string   `json:"jsonrpc"`
+	RpcError RPCError `json:"error"`
+	ID       ID       `json:"id"`
+}
+
+func (p InternalError) Error() string {
+	return p.RpcError.Message
+}
+
+func NewInternalError(id ID, details ...Detail) InternalError {
+	detailsMap := map[string]interface{}{}
+	for _, d := range details {
+		detailsMap[d.Key()] = d.Value()
+	}
+	return InternalError{
+		JsonRPC:  "2.0",
+		RpcError: RPCError{Code: -32603, Message: "Internal error", Data: detailsMap},
+		ID:       id,
+	}
+}
+
+func (p InternalError) JSONRPCBytes() []byte {
+	b, err := json.Marshal(p)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
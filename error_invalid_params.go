@@ -0,0 +1,33 @@
+package jsonrpc
+
+import "encoding/json"
+
+type InvalidParamsError struct {
+	JsonRPC  string   `json:"jsonrpc"`
+	RpcError RPCError `json:"error"`
+	ID       ID       `json:"id"`
+}
+
+func (p InvalidParamsError) Error() string {
+	return p.RpcError.Message
+}
+
+func NewInvalidParamsError(id ID, details ...Detail) InvalidParamsError {
+	detailsMap := map[string]interface{}{}
+	for _, d := range details {
+		detailsMap[d.Key()] = d.Value()
+	}
+	return InvalidParamsError{
+		JsonRPC:  "2.0",
+		RpcError: RPCError{Code: -32602, Message: "Invalid params", Data: detailsMap},
+		ID:       id,
+	}
+}
+
+func (p InvalidParamsError) JSONRPCBytes() []byte {
+	b, err := json.Marshal(p)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
@@ -5,14 +5,14 @@ import "encoding/json"
 type InvalidRequestError struct {
 	JsonRPC  string   `json:"jsonrpc"`
 	RpcError RPCError `json:"error"`
-	ID       *string  `json:"id"`
+	ID       ID       `json:"id"`
 }
 
 func (p InvalidRequestError) Error() string {
 	return p.RpcError.Message
 }
 
-func NewInvalidRequestError(id *string, details ...Detail) InvalidRequestError {
+func NewInvalidRequestError(id ID, details ...Detail) InvalidRequestError {
 	detailsMap := map[string]interface{}{}
 	for _, d := range details {
 		detailsMap[d.Key()] = d.Value()
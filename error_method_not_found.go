@@ -5,14 +5,14 @@ import "encoding/json"
 type MethodNotFoundError struct {
 	JsonRPC  string   `json:"jsonrpc"`
 	RpcError RPCError `json:"error"`
-	ID       *string  `json:"id"`
+	ID       ID       `json:"id"`
 }
 
 func (p MethodNotFoundError) Error() string {
 	return p.RpcError.Message
 }
 
-func NewMethodNotFoundError(details ...Detail) MethodNotFoundError {
+func NewMethodNotFoundError(id ID, details ...Detail) MethodNotFoundError {
 	detailsMap := map[string]interface{}{}
 	for _, d := range details {
 		detailsMap[d.Key()] = d.Value()
@@ -20,7 +20,7 @@ func NewMethodNotFoundError(details ...Detail) MethodNotFoundError {
 	return MethodNotFoundError{
 		JsonRPC:  "2.0",
 		RpcError: RPCError{Code: -32601, Message: "Method not found", Data: detailsMap},
-		ID:       nil,
+		ID:       id,
 	}
 }
 
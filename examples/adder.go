@@ -2,8 +2,6 @@ package examples
 
 import (
 	"context"
-	"encoding/json"
-	"errors"
 	"github.com/calmdaysamuel/jsonrpc"
 	"log"
 	"net/http"
@@ -17,41 +15,14 @@ func main() {
 }
 func Adder() jsonrpc.Server {
 	s := jsonrpc.New(jsonrpc.WithMaxBatchSize(15), jsonrpc.WithMaxRequestSize(2*1024*1024), jsonrpc.WithBatchRequestParallelism(16))
-	s.Register(&adder{})
+	s.Register(jsonrpc.NewTypedHandler("add", add))
 	return s
 }
 
-type adder struct {
-}
-
-func (a *adder) MethodName() string {
-	return "add"
-}
-
-func (a *adder) Execute(ctx context.Context, headers http.Header, id *string, params interface{}) (interface{}, error) {
-	b, err := json.Marshal(params)
-	if err != nil {
-		return nil, errors.New("parameters MUST be an int64 array")
-	}
-	var intArray []int64
-	if err := json.Unmarshal(b, &intArray); err != nil {
-		return nil, errors.New("parameters MUST be an int64 array")
-	}
+func add(ctx context.Context, headers http.Header, id jsonrpc.ID, params []int64) (int64, error) {
 	sum := int64(0)
-	for _, i := range intArray {
+	for _, i := range params {
 		sum += i
 	}
 	return sum, nil
 }
-
-func (a *adder) ParametersValid(ctx context.Context, params interface{}) ([]jsonrpc.Detail, bool) {
-	b, err := json.Marshal(params)
-	if err != nil {
-		return []jsonrpc.Detail{jsonrpc.NewDetail("rationale", "parameters MUST be an int64 array")}, false
-	}
-	var intArray []int64
-	if err := json.Unmarshal(b, &intArray); err != nil {
-		return []jsonrpc.Detail{jsonrpc.NewDetail("rationale", "parameters MUST be an int64 array")}, false
-	}
-	return nil, true
-}
@@ -0,0 +1,48 @@
+package calculator
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+//go:generate go run github.com/calmdaysamuel/jsonrpc/cmd/jsonrpc-gen -type Calculator -prefix calc
+
+type Calculator interface {
+	Add(ctx context.Context, params Operands) (int64, error)
+	Divide(ctx context.Context, params Operands) (Quotient, error)
+	Uptime(ctx context.Context, params struct{}) (time.Duration, error)
+}
+
+type Operands struct {
+	A int64 `json:"a"`
+	B int64 `json:"b"`
+}
+
+type Quotient struct {
+	Quotient  int64 `json:"quotient"`
+	Remainder int64 `json:"remainder"`
+}
+
+type calculator struct {
+	started time.Time
+}
+
+func New() Calculator {
+	return &calculator{started: time.Now()}
+}
+
+func (c *calculator) Add(ctx context.Context, params Operands) (int64, error) {
+	return params.A + params.B, nil
+}
+
+func (c *calculator) Divide(ctx context.Context, params Operands) (Quotient, error) {
+	if params.B == 0 {
+		return Quotient{}, errors.New("division by zero")
+	}
+	return Quotient{Quotient: params.A / params.B, Remainder: params.A % params.B}, nil
+}
+
+func (c *calculator) Uptime(ctx context.Context, params struct{}) (time.Duration, error) {
+	return time.Since(c.started), nil
+}
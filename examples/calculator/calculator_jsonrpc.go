@@ -0,0 +1,54 @@
+// Code generated by jsonrpc-gen. DO NOT EDIT.
+
+package calculator
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/calmdaysamuel/jsonrpc"
+)
+
+// CalculatorClient calls the Calculator methods of a JSON-RPC server.
+type CalculatorClient struct {
+	client *jsonrpc.Client
+}
+
+var _ Calculator = (*CalculatorClient)(nil)
+
+// NewCalculatorClient returns a CalculatorClient sending its calls with client.
+func NewCalculatorClient(client *jsonrpc.Client) *CalculatorClient {
+	return &CalculatorClient{client: client}
+}
+
+func (c *CalculatorClient) Add(ctx context.Context, params Operands) (int64, error) {
+	var result int64
+	err := c.client.Call(ctx, "calc.Add", params, &result)
+	return result, err
+}
+
+func (c *CalculatorClient) Divide(ctx context.Context, params Operands) (Quotient, error) {
+	var result Quotient
+	err := c.client.Call(ctx, "calc.Divide", params, &result)
+	return result, err
+}
+
+func (c *CalculatorClient) Uptime(ctx context.Context, params struct{}) (time.Duration, error) {
+	var result time.Duration
+	err := c.client.Call(ctx, "calc.Uptime", params, &result)
+	return result, err
+}
+
+// RegisterCalculator registers every method of impl with s.
+func RegisterCalculator(s jsonrpc.Server, impl Calculator) {
+	s.Register(jsonrpc.NewTypedHandler("calc.Add", func(ctx context.Context, headers http.Header, id jsonrpc.ID, params Operands) (int64, error) {
+		return impl.Add(ctx, params)
+	}))
+	s.Register(jsonrpc.NewTypedHandler("calc.Divide", func(ctx context.Context, headers http.Header, id jsonrpc.ID, params Operands) (Quotient, error) {
+		return impl.Divide(ctx, params)
+	}))
+	s.Register(jsonrpc.NewTypedHandler("calc.Uptime", func(ctx context.Context, headers http.Header, id jsonrpc.ID, params struct{}) (time.Duration, error) {
+		return impl.Uptime(ctx, params)
+	}))
+}
@@ -0,0 +1,87 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strconv"
+)
+
+// ID identifies a JSON-RPC request. It keeps the raw JSON form of the id so that strings,
+// numbers (in their exact textual form) and null round-trip unchanged.
+// A nil ID means the id member was absent, which marks a request as a notification.
+type ID []byte
+
+// StringID returns an ID holding the string s.
+func StringID(s string) ID {
+	b, _ := json.Marshal(s)
+	return b
+}
+
+// IntID returns an ID holding the number n.
+func IntID(n int64) ID {
+	return ID(strconv.FormatInt(n, 10))
+}
+
+// NullID returns an ID holding an explicit JSON null.
+func NullID() ID {
+	return ID("null")
+}
+
+// IsNull reports whether the id is absent or an explicit null.
+func (id ID) IsNull() bool {
+	return len(id) == 0 || string(id) == "null"
+}
+
+// IsString reports whether the id is a JSON string.
+func (id ID) IsString() bool {
+	return len(id) > 0 && id[0] == '"'
+}
+
+// IsNumber reports whether the id is a JSON number.
+func (id ID) IsNumber() bool {
+	return len(id) > 0 && id[0] != '"' && !id.IsNull()
+}
+
+// Equal reports whether both ids have the same JSON form.
+func (id ID) Equal(other ID) bool {
+	return bytes.Equal(id, other)
+}
+
+// String returns the value of a string id, the literal of a numeric id, "null" for a null id
+// and an empty string for an absent id.
+func (id ID) String() string {
+	if id.IsString() {
+		var s string
+		if err := json.Unmarshal(id, &s); err == nil {
+			return s
+		}
+	}
+	return string(id)
+}
+
+func (id ID) MarshalJSON() ([]byte, error) {
+	if len(id) == 0 {
+		return []byte("null"), nil
+	}
+	return id, nil
+}
+
+var (
+	errEmptyID   = errors.New("jsonrpc: empty id")
+	errInvalidID = errors.New("jsonrpc: id must be a string, a number or null")
+)
+
+func (id *ID) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return errEmptyID
+	}
+	switch c := data[0]; {
+	case c == '"', c == '-', c >= '0' && c <= '9', string(data) == "null":
+	default:
+		return errInvalidID
+	}
+	*id = append((*id)[:0], data...)
+	return nil
+}
@@ -0,0 +1,29 @@
+package jsonrpc
+
+import (
+	"context"
+	"net/http"
+)
+
+// Invoker executes a rpc request and returns its result.
+type Invoker func(ctx context.Context, headers http.Header, request Request) (interface{}, error)
+
+// Interceptor wraps the execution of every rpc request, single or batched.
+// It can inspect the request, headers and context before calling next, and the result or error after.
+// Returning without calling next short-circuits the call, e.g. to reject an unauthenticated request.
+// The request passed to next can be modified: when its Method is rewritten, the handler of the new method is
+// executed, or a method not found error is returned if there is none.
+type Interceptor func(ctx context.Context, headers http.Header, request Request, next Invoker) (interface{}, error)
+
+// chainInterceptors returns an Invoker that runs the interceptors in order around final.
+// The first interceptor is the outermost one.
+func chainInterceptors(interceptors []Interceptor, final Invoker) Invoker {
+	invoker := final
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, next := interceptors[i], invoker
+		invoker = func(ctx context.Context, headers http.Header, request Request) (interface{}, error) {
+			return interceptor(ctx, headers, request, next)
+		}
+	}
+	return invoker
+}
@@ -0,0 +1,64 @@
+package jsonrpc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterceptors(t *testing.T) {
+	var lock sync.Mutex
+	var calls []string
+	record := func(name string) Interceptor {
+		return func(ctx context.Context, headers http.Header, request Request, next Invoker) (interface{}, error) {
+			lock.Lock()
+			calls = append(calls, name+":"+request.Method)
+			lock.Unlock()
+			return next(ctx, headers, request)
+		}
+	}
+	auth := func(ctx context.Context, headers http.Header, request Request, next Invoker) (interface{}, error) {
+		if headers.Get("Authorization") != "secret" {
+			return nil, NewServerError(request.ID, "Unauthorized", -32001)
+		}
+		return next(ctx, headers, request)
+	}
+	s := New(WithInterceptors(record("first"), record("second")), WithInterceptors(auth))
+	s.Register(NewTypedHandler("echo", echo))
+
+	recorder := serve(t, s, `{"jsonrpc":"2.0","method":"echo","id":1,"params":"hi"}`)
+	require.Contains(t, recorder.Body.String(), `"code":-32001`)
+	require.Equal(t, []string{"first:echo", "second:echo"}, calls)
+
+	calls = nil
+	recorder = httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(`[{"jsonrpc":"2.0","method":"echo","id":1,"params":"a"},{"jsonrpc":"2.0","method":"echo","id":2,"params":"b"}]`))
+	request.Header.Set("Authorization", "secret")
+	s.ServeHTTP(recorder, request)
+	require.Contains(t, recorder.Body.String(), `"result":"a"`)
+	require.Contains(t, recorder.Body.String(), `"result":"b"`)
+	require.Len(t, calls, 4)
+}
+
+func TestInterceptorRewritesMethod(t *testing.T) {
+	rewrite := func(ctx context.Context, headers http.Header, request Request, next Invoker) (interface{}, error) {
+		request.Method = strings.TrimPrefix(request.Method, "v1.")
+		return next(ctx, headers, request)
+	}
+	s := New(WithInterceptors(rewrite))
+	s.Register(NewTypedHandler("echo", echo))
+	s.Register(NewTypedHandler("v1.echo", func(ctx context.Context, headers http.Header, id ID, params string) (string, error) {
+		return "deprecated", nil
+	}))
+	s.Register(NewTypedHandler("v1.missing", echo))
+
+	recorder := serve(t, s, `{"jsonrpc":"2.0","method":"v1.echo","id":1,"params":"hi"}`)
+	require.Equal(t, `{"jsonrpc":"2.0","result":"hi","id":1}`, recorder.Body.String())
+	recorder = serve(t, s, `{"jsonrpc":"2.0","method":"v1.missing","id":2,"params":"hi"}`)
+	require.Contains(t, recorder.Body.String(), `"code":-32601`)
+}
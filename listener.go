@@ -0,0 +1,122 @@
+package jsonrpc
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+)
+
+// CodecFunc creates the Codec used to frame messages on a connection.
+// [NewHeaderCodec], [NewLineCodec] and [NewLengthPrefixCodec] can all be used as a CodecFunc.
+type CodecFunc = func(r io.Reader, w io.Writer) Codec
+
+// tlsHandshakeTimeout bounds how long a client can take to complete the TLS handshake.
+const tlsHandshakeTimeout = 10 * time.Second
+
+// Serve accepts connections on listener, e.g. a TCP or Unix domain socket, and serves rpc requests on each of them
+// with the framing of the codecs created by newCodec. Every connection executes up to batchRequestParallelism
+// messages concurrently. Connections are wrapped in TLS when it is configured.
+// Serve returns when accepting a connection fails, or nil once Shutdown is called.
+func (j *jsonRPCServer) Serve(listener net.Listener, newCodec CodecFunc) error {
+	tlsConfig, err := j.opts.tlsConfig()
+	if err != nil {
+		return err
+	}
+	if tlsConfig != nil {
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+	if !j.track(func() { j.listeners[listener] = struct{}{} }) {
+		return http.ErrServerClosed
+	}
+	var retryDelay time.Duration
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if j.draining.Load() {
+				return nil
+			}
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				retryDelay = min(max(2*retryDelay, 5*time.Millisecond), time.Second)
+				slog.Error("Failed to accept connection, retrying", "error", err, "delay", retryDelay)
+				time.Sleep(retryDelay)
+				continue
+			}
+			return err
+		}
+		retryDelay = 0
+		go j.serveConn(conn, newCodec)
+	}
+}
+
+func (j *jsonRPCServer) serveConn(conn net.Conn, newCodec CodecFunc) {
+	defer func() {
+		if err := conn.Close(); err != nil {
+			slog.Error("Failed to close connection", "error", err)
+		}
+	}()
+	params := []Param{LogOnlyParam("remoteAddr", conn.RemoteAddr().String())}
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		_ = tlsConn.SetDeadline(time.Now().Add(tlsHandshakeTimeout))
+		if err := tlsConn.Handshake(); err != nil {
+			slog.Error("Failed TLS handshake", "error", err, "remoteAddr", conn.RemoteAddr().String())
+			return
+		}
+		_ = tlsConn.SetDeadline(time.Time{})
+		state := tlsConn.ConnectionState()
+		params = append(params, peerParams(&state)...)
+	}
+	ctx := ContextWithParams(context.Background(), params...)
+	if err := j.serveMessages(ctx, http.Header{}, newCodec(conn, conn)); err != nil {
+		slog.Error("Failed to read rpc message", "error", err, "remoteAddr", conn.RemoteAddr().String())
+	}
+}
+
+// NewLengthPrefixCodec returns a Codec that prefixes every message with its length as a 4 byte big-endian integer.
+func NewLengthPrefixCodec(r io.Reader, w io.Writer) Codec {
+	return &lengthPrefixCodec{
+		reader:    r,
+		writer:    w,
+		readLimit: defaultOpts().maxRequestSize,
+	}
+}
+
+type lengthPrefixCodec struct {
+	reader    io.Reader
+	writer    io.Writer
+	readLimit int64
+}
+
+func (l *lengthPrefixCodec) setReadLimit(limit int64) {
+	l.readLimit = limit
+}
+
+func (l *lengthPrefixCodec) ReadMessage() ([]byte, error) {
+	var prefix [4]byte
+	if _, err := io.ReadFull(l.reader, prefix[:]); err != nil {
+		return nil, err
+	}
+	length := binary.BigEndian.Uint32(prefix[:])
+	if int64(length) > l.readLimit {
+		return nil, errMessageTooLarge
+	}
+	message := make([]byte, length)
+	if _, err := io.ReadFull(l.reader, message); err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+func (l *lengthPrefixCodec) WriteMessage(message []byte) error {
+	frame := make([]byte, 4, len(message)+4)
+	binary.BigEndian.PutUint32(frame, uint32(len(message)))
+	frame = append(frame, message...)
+	_, err := l.writer.Write(frame)
+	return err
+}
@@ -0,0 +1,47 @@
+package jsonrpc
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestServeListener(t *testing.T) {
+	s := New()
+	s.Register(NewTypedHandler("echo", echo))
+
+	for _, tc := range []struct {
+		network  string
+		address  string
+		newCodec CodecFunc
+	}{
+		{network: "tcp", address: "127.0.0.1:0", newCodec: NewLengthPrefixCodec},
+		{network: "unix", address: filepath.Join(t.TempDir(), "rpc.sock"), newCodec: NewLineCodec},
+	} {
+		t.Run(tc.network, func(t *testing.T) {
+			listener, err := net.Listen(tc.network, tc.address)
+			require.NoError(t, err)
+			defer listener.Close()
+			go func() { _ = s.Serve(listener, tc.newCodec) }()
+
+			conn, err := net.Dial(tc.network, listener.Addr().String())
+			require.NoError(t, err)
+			defer conn.Close()
+			codec := tc.newCodec(conn, conn)
+			require.NoError(t, codec.WriteMessage([]byte(`{"jsonrpc":"2.0","method":"echo","id":1,"params":"hi"}`)))
+			require.NoError(t, codec.WriteMessage([]byte(`{"jsonrpc":"2.0","method":"echo","id":2,"params":"there"}`)))
+			responses := map[string]bool{}
+			for range 2 {
+				message, err := codec.ReadMessage()
+				require.NoError(t, err)
+				responses[string(message)] = true
+			}
+			require.Equal(t, map[string]bool{
+				`{"jsonrpc":"2.0","result":"hi","id":1}`:    true,
+				`{"jsonrpc":"2.0","result":"there","id":2}`: true,
+			}, responses)
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// namedParam is a parameter declared with WithParamNames.
+type namedParam struct {
+	name     string
+	optional bool
+}
+
+// parseParamNames parses the names declared with WithParamNames for the params type t.
+// It panics if a required name follows an optional one, if a name is repeated, or if t is a struct without a
+// field of that name.
+func parseParamNames(t reflect.Type, names []string) []namedParam {
+	if len(names) == 0 {
+		return nil
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	isStruct := t.Kind() == reflect.Struct
+	var fields []string
+	if isStruct {
+		for _, field := range jsonFields(t) {
+			fields = append(fields, field.name)
+		}
+	}
+	params := make([]namedParam, 0, len(names))
+	for _, name := range names {
+		param := namedParam{name: strings.TrimSuffix(name, "?"), optional: strings.HasSuffix(name, "?")}
+		switch {
+		case param.name == "":
+			panic("param names cannot be empty")
+		case slices.ContainsFunc(params, func(p namedParam) bool { return p.name == param.name }):
+			panic("param " + param.name + " is declared twice")
+		case !param.optional && len(params) > 0 && params[len(params)-1].optional:
+			panic("required param " + param.name + " follows an optional param")
+		case isStruct && !slices.Contains(fields, param.name):
+			panic("param " + param.name + " is not a field of " + t.String())
+		}
+		params = append(params, param)
+	}
+	return params
+}
+
+// nameParams returns the parameters of a request as an object holding the declared params, whether they were sent
+// by position or by name. The returned details explain why the parameters are refused, if they are.
+func nameParams(declared []namedParam, params interface{}) (json.RawMessage, []Detail) {
+	raw, ok := params.(json.RawMessage)
+	if !ok && params != nil {
+		b, err := json.Marshal(params)
+		if err != nil {
+			return nil, []Detail{NewDetail("rationale", err.Error())}
+		}
+		raw = b
+	}
+	raw = bytes.TrimSpace(raw)
+
+	named := map[string]json.RawMessage{}
+	var unexpected []string
+	switch {
+	case len(raw) == 0 || bytes.Equal(raw, []byte("null")):
+	case raw[0] == '[':
+		var positional []json.RawMessage
+		if err := json.Unmarshal(raw, &positional); err != nil {
+			return nil, []Detail{NewDetail("rationale", err.Error())}
+		}
+		for i, value := range positional {
+			if i >= len(declared) {
+				unexpected = append(unexpected, fmt.Sprintf("[%d]", i))
+				continue
+			}
+			named[declared[i].name] = value
+		}
+	case raw[0] == '{':
+		if err := json.Unmarshal(raw, &named); err != nil {
+			return nil, []Detail{NewDetail("rationale", err.Error())}
+		}
+		for name := range named {
+			if !slices.ContainsFunc(declared, func(p namedParam) bool { return p.name == name }) {
+				unexpected = append(unexpected, name)
+			}
+		}
+		slices.Sort(unexpected)
+	default:
+		return nil, []Detail{NewDetail("rationale", "Params must be an array or an object")}
+	}
+
+	var missing []string
+	for _, param := range declared {
+		if _, ok := named[param.name]; !ok && !param.optional {
+			missing = append(missing, param.name)
+		}
+	}
+	var details []Detail
+	if missing != nil {
+		details = append(details, NewDetail("missing", missing))
+	}
+	if unexpected != nil {
+		details = append(details, NewDetail("unexpected", unexpected))
+	}
+	if details != nil {
+		return nil, details
+	}
+	b, err := json.Marshal(named)
+	if err != nil {
+		return nil, []Detail{NewDetail("rationale", err.Error())}
+	}
+	return b, nil
+}
@@ -0,0 +1,95 @@
+package jsonrpc
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type subtractParams struct {
+	Minuend    float64 `json:"minuend"`
+	Subtrahend float64 `json:"subtrahend"`
+	Scale      *int    `json:"scale"`
+}
+
+func subtract(ctx context.Context, headers http.Header, id ID, params subtractParams) (float64, error) {
+	result := params.Minuend - params.Subtrahend
+	if params.Scale != nil {
+		result *= float64(*params.Scale)
+	}
+	return result, nil
+}
+
+func TestParamNames(t *testing.T) {
+	s := New()
+	s.Register(NewTypedHandler("subtract", subtract, WithParamNames("minuend", "subtrahend", "scale?")))
+
+	tests := []struct {
+		params   string
+		response string
+	}{
+		{params: `[42, 23]`, response: `{"jsonrpc":"2.0","result":19,"id":1}`},
+		{params: `[42, 23, 2]`, response: `{"jsonrpc":"2.0","result":38,"id":1}`},
+		{params: `{"subtrahend": 23, "minuend": 42}`, response: `{"jsonrpc":"2.0","result":19,"id":1}`},
+		{params: `{"subtrahend": 23, "minuend": 42, "scale": 3}`, response: `{"jsonrpc":"2.0","result":57,"id":1}`},
+		{
+			params:   `[42]`,
+			response: `{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params","data":{"missing":["subtrahend"]}},"id":1}`,
+		},
+		{
+			params:   `[42, 23, 2, 1]`,
+			response: `{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params","data":{"unexpected":["[3]"]}},"id":1}`,
+		},
+		{
+			params:   `{"minuend": 42, "precision": 2, "offset": 1}`,
+			response: `{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params","data":{"missing":["subtrahend"],"unexpected":["offset","precision"]}},"id":1}`,
+		},
+		{
+			params:   `null`,
+			response: `{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params","data":{"missing":["minuend","subtrahend"]}},"id":1}`,
+		},
+		{
+			params:   `"42"`,
+			response: `{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params","data":{"rationale":"Params must be an array or an object"}},"id":1}`,
+		},
+	}
+	for _, test := range tests {
+		recorder := serve(t, s, `{"jsonrpc":"2.0","method":"subtract","id":1,"params":`+test.params+`}`)
+		require.JSONEq(t, test.response, recorder.Body.String(), test.params)
+	}
+
+	method := describeMethod(NewTypedHandler("subtract", subtract, WithParamNames("minuend", "subtrahend", "scale?")), nil)
+	require.Equal(t, "either", method.ParamStructure)
+	require.Equal(t, []OpenRPCContentDescriptor{
+		{Name: "minuend", Required: true, Schema: map[string]interface{}{"type": "number"}},
+		{Name: "subtrahend", Required: true, Schema: map[string]interface{}{"type": "number"}},
+		{Name: "scale", Schema: map[string]interface{}{"type": "integer"}},
+	}, method.Params)
+}
+
+func TestParamNamesMisuse(t *testing.T) {
+	require.PanicsWithValue(t, "required param subtrahend follows an optional param", func() {
+		NewTypedHandler("subtract", subtract, WithParamNames("minuend?", "subtrahend"))
+	})
+	require.PanicsWithValue(t, "param precision is not a field of jsonrpc.subtractParams", func() {
+		NewTypedHandler("subtract", subtract, WithParamNames("minuend", "precision"))
+	})
+	require.PanicsWithValue(t, "param minuend is declared twice", func() {
+		NewTypedHandler("subtract", subtract, WithParamNames("minuend", "minuend?"))
+	})
+	require.PanicsWithValue(t, "param a is not a field of struct {}", func() {
+		NewTypedHandler("empty", func(ctx context.Context, headers http.Header, id ID, params struct{}) (string, error) {
+			return "", nil
+		}, WithParamNames("a"))
+	})
+	type hidden struct {
+		A int `json:"-"`
+	}
+	require.PanicsWithValue(t, "param A is not a field of jsonrpc.hidden", func() {
+		NewTypedHandler("hidden", func(ctx context.Context, headers http.Header, id ID, params hidden) (string, error) {
+			return "", nil
+		}, WithParamNames("A"))
+	})
+}
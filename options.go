@@ -1,11 +1,30 @@
 package jsonrpc
 
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+)
+
 type Option = func(opts *serverOpts)
 
 type serverOpts struct {
 	maxRequestSize          int64
 	batchRequestParallelism int
 	maxBatchSize            int
+	interceptors            []Interceptor
+	rpcPath                 string
+	healthEndpoints         bool
+	tls                     *tls.Config
+	certFile                string
+	keyFile                 string
+	clientCAs               *x509.CertPool
+	openRPCTitle            string
+	openRPCVersion          string
+	paramsSchemas           map[string]json.RawMessage
+	orderedBatchResponses   bool
+	sequentialBatches       bool
+	websocketOrigins        []string
 }
 
 func defaultOpts() *serverOpts {
@@ -13,6 +32,11 @@ func defaultOpts() *serverOpts {
 		maxRequestSize:          1024 * 1024 * 1024, // 1mb
 		batchRequestParallelism: 8,
 		maxBatchSize:            25,
+		rpcPath:                 "/rpc",
+		healthEndpoints:         true,
+		openRPCTitle:            "jsonrpc",
+		openRPCVersion:          "1.0.0",
+		paramsSchemas:           map[string]json.RawMessage{},
 	}
 }
 
@@ -33,3 +57,95 @@ func WithMaxBatchSize(batchSize int) Option {
 		opts.maxBatchSize = batchSize
 	}
 }
+
+// WithOrderedBatchResponses controls whether the responses of a batch are sent in the order of its requests.
+// By default they are sent in the order the requests complete. Requests are still executed concurrently.
+func WithOrderedBatchResponses(enabled bool) Option {
+	return func(opts *serverOpts) {
+		opts.orderedBatchResponses = enabled
+	}
+}
+
+// WithSequentialBatchExecution controls whether the requests of a batch are executed one after the other, in order,
+// e.g. when they have side effects that depend on each other. Their responses are then in the order of the requests.
+// By default up to batchRequestParallelism requests of a batch are executed concurrently.
+func WithSequentialBatchExecution(enabled bool) Option {
+	return func(opts *serverOpts) {
+		opts.sequentialBatches = enabled
+	}
+}
+
+// WithInterceptors adds interceptors around the execution of every rpc request.
+// Interceptors run in the order they are given, after any interceptors added before.
+func WithInterceptors(interceptors ...Interceptor) Option {
+	return func(opts *serverOpts) {
+		opts.interceptors = append(opts.interceptors, interceptors...)
+	}
+}
+
+// WithRPCPath sets the path of the rpc endpoint, "/rpc" by default. Requests to sub paths are served as well, and
+// "/v1/rpc/" serves the same requests as "/v1/rpc". Use "/" to serve rpc requests on every path, e.g. when mounted
+// behind http.StripPrefix. New panics if the path does not start with "/".
+func WithRPCPath(path string) Option {
+	return func(opts *serverOpts) {
+		opts.rpcPath = path
+	}
+}
+
+// WithHealthEndpoints controls whether the /health, /readiness and /liveliness endpoints are served.
+// They are served by default.
+func WithHealthEndpoints(enabled bool) Option {
+	return func(opts *serverOpts) {
+		opts.healthEndpoints = enabled
+	}
+}
+
+// WithParamsSchema validates the parameters of method against a JSON Schema before it is executed.
+// Parameters that do not match are refused with an invalid params error whose "violations" data lists every
+// [SchemaViolation]. A subset of draft 2020-12 is supported: the type, enum, const, numeric, string, array and object
+// keywords, the applicators allOf, anyOf, oneOf and not, and $ref within the schema. New panics if the schema is invalid.
+// The numeric keywords refuse numbers longer than 400 characters or with an exponent beyond 400.
+func WithParamsSchema(method string, schema json.RawMessage) Option {
+	return func(opts *serverOpts) {
+		opts.paramsSchemas[method] = schema
+	}
+}
+
+// WithOpenRPCInfo sets the title and version of the API in the OpenRPC document served by rpc.discover.
+func WithOpenRPCInfo(title string, version string) Option {
+	return func(opts *serverOpts) {
+		opts.openRPCTitle = title
+		opts.openRPCVersion = version
+	}
+}
+
+// WithTLSCertFiles serves TLS using the PEM encoded certificate and key files.
+func WithTLSCertFiles(certFile string, keyFile string) Option {
+	return func(opts *serverOpts) {
+		opts.certFile = certFile
+		opts.keyFile = keyFile
+	}
+}
+
+// WithTLSConfig serves TLS using config. It can be combined with [WithTLSCertFiles] and [WithClientCAs].
+func WithTLSConfig(config *tls.Config) Option {
+	return func(opts *serverOpts) {
+		opts.tls = config
+	}
+}
+
+// WithClientCAs enables mutual TLS: clients must present a certificate signed by one of the authorities in pool.
+// The verified certificate is available to handlers through [PeerCertificateFromContext].
+func WithClientCAs(pool *x509.CertPool) Option {
+	return func(opts *serverOpts) {
+		opts.clientCAs = pool
+	}
+}
+
+// WithWebSocketOrigins allows websocket upgrades from pages of the given origins, e.g. "https://app.example.com",
+// in addition to the origin of the server itself. "*" allows every origin.
+func WithWebSocketOrigins(origins ...string) Option {
+	return func(opts *serverOpts) {
+		opts.websocketOrigins = append(opts.websocketOrigins, origins...)
+	}
+}
@@ -47,11 +47,11 @@ func LogOnlyParam(key string, val interface{}) Param {
 }
 
 func ContextWithParams(ctx context.Context, params ...Param) context.Context {
-	val, ok := ctx.Value(paramsKey).(map[string]Param)
-	if !ok {
-		val = map[string]Param{}
+	parent, _ := ctx.Value(paramsKey).(map[string]Param)
+	val := make(map[string]Param, len(parent)+len(params))
+	for key, param := range parent {
+		val[key] = param
 	}
-
 	for _, param := range params {
 		val[param.Key()] = param
 	}
@@ -69,3 +69,13 @@ func ParamsFromContext(ctx context.Context) []Param {
 	}
 	return params
 }
+
+// ParamFromContext returns the param stored in ctx under key.
+func ParamFromContext(ctx context.Context, key string) (Param, bool) {
+	val, ok := ctx.Value(paramsKey).(map[string]Param)
+	if !ok {
+		return nil, false
+	}
+	param, ok := val[key]
+	return param, ok
+}
@@ -1,10 +1,21 @@
 package jsonrpc
 
+import "encoding/json"
+
 type BatchRequest = []Request
 
 type Request struct {
-	JSONRPC string      `json:"jsonrpc"`
-	Method  string      `json:"method"`
-	ID      *string     `json:"id,omitempty"`
-	Params  interface{} `json:"params,omitempty"`
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	ID      ID     `json:"id,omitempty"`
+	// Params holds the raw JSON of the parameters until a handler decodes them. It is nil when they are absent.
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// params returns the parameters as passed to handlers: nil when they are absent, and a json.RawMessage otherwise.
+func (r Request) params() interface{} {
+	if len(r.Params) == 0 {
+		return nil
+	}
+	return r.Params
 }
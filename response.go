@@ -8,10 +8,10 @@ type Response struct {
 	JsonRPC string      `json:"jsonrpc"`
 	Result  interface{} `json:"result,omitempty"`
 	Error   interface{} `json:"error,omitempty"`
-	ID      *string     `json:"id"`
+	ID      ID          `json:"id"`
 }
 
-func NewResponse(id *string, result interface{}) Response {
+func NewResponse(id ID, result interface{}) Response {
 	return Response{
 		JsonRPC: "2.0",
 		Result:  result,
@@ -19,6 +19,23 @@ func NewResponse(id *string, result interface{}) Response {
 	}
 }
 
+// MarshalJSON encodes the response with either its result or its error. The result is always present in successful
+// responses, even when it is null.
+func (p Response) MarshalJSON() ([]byte, error) {
+	if p.Error != nil {
+		return json.Marshal(struct {
+			JsonRPC string      `json:"jsonrpc"`
+			Error   interface{} `json:"error"`
+			ID      ID          `json:"id"`
+		}{JsonRPC: p.JsonRPC, Error: p.Error, ID: p.ID})
+	}
+	return json.Marshal(struct {
+		JsonRPC string      `json:"jsonrpc"`
+		Result  interface{} `json:"result"`
+		ID      ID          `json:"id"`
+	}{JsonRPC: p.JsonRPC, Result: p.Result, ID: p.ID})
+}
+
 func (p Response) JSONRPCBytes() []byte {
 	b, err := json.Marshal(p)
 	if err != nil {
@@ -0,0 +1,81 @@
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// RetryPolicy configures how the client retries failed calls. Retries are never applied to notifications,
+// to methods marked with [WithNonIdempotentMethods], or to batches containing either of them.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts of a call, including the first one. Values below 2 disable retries.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry, 100ms if zero.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between two attempts, 5s if zero.
+	MaxBackoff time.Duration
+	// Multiplier is the factor applied to the delay after every attempt, 2 if zero.
+	Multiplier float64
+	// Jitter is the fraction, between 0 and 1, of every delay that is randomized.
+	Jitter float64
+	// RetryableCodes are the JSON-RPC error codes for which a call is retried.
+	// Transport errors, 429 and 5xx http statuses are always retried.
+	RetryableCodes []int
+}
+
+// backoff returns the delay before the retry following attempt, where the first attempt is 0.
+func (r RetryPolicy) backoff(attempt int) time.Duration {
+	delay, maxDelay, multiplier := r.InitialBackoff, r.MaxBackoff, r.Multiplier
+	if delay <= 0 {
+		delay = 100 * time.Millisecond
+	}
+	if maxDelay <= 0 {
+		maxDelay = 5 * time.Second
+	}
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+	backoff := float64(delay)
+	for i := 0; i < attempt && backoff < float64(maxDelay); i++ {
+		backoff *= multiplier
+	}
+	backoff = min(backoff, float64(maxDelay))
+	if jitter := min(max(r.Jitter, 0), 1); jitter > 0 {
+		backoff -= backoff * jitter * rand.Float64()
+	}
+	return time.Duration(backoff)
+}
+
+// retryableCode reports whether calls failing with the JSON-RPC error code should be retried.
+func (r RetryPolicy) retryableCode(code int) bool {
+	return slices.Contains(r.RetryableCodes, code)
+}
+
+// retryableError reports whether a request failing with err should be retried.
+// Requests are not retried once the context of the caller is done.
+func retryableError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, ErrCircuitOpen) {
+		return false
+	}
+	var httpError *HTTPError
+	if errors.As(err, &httpError) {
+		return httpError.StatusCode == http.StatusTooManyRequests || httpError.StatusCode >= 500
+	}
+	return true
+}
+
+// sleep waits for delay or until ctx is done.
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,658 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// SchemaViolation is a keyword of a JSON Schema that the parameters of a request do not satisfy.
+// The violations are listed under the "violations" key of the data of the invalid params error.
+type SchemaViolation struct {
+	// Pointer is the JSON pointer of the violating value within the parameters, "" being the parameters themselves.
+	Pointer string `json:"pointer"`
+	// Keyword is the schema keyword that is not satisfied, e.g. "required" or "type".
+	Keyword string `json:"keyword"`
+	Message string `json:"message"`
+}
+
+// schema is a compiled JSON Schema. It supports the following subset of draft 2020-12:
+// boolean schemas, type, enum, const, the numeric, string, array and object assertions except format, contains,
+// dependentRequired and the unevaluated keywords, allOf, anyOf, oneOf, not, and $ref to a JSON pointer within the
+// same document ("#" or "#/$defs/name"). Any other keyword is ignored.
+type schema struct {
+	// allow is set for the boolean schemas true and false, in which case no other field is.
+	allow *bool
+
+	types    []string
+	enum     []interface{}
+	constant *interface{}
+
+	multipleOf       *big.Rat
+	maximum          *big.Rat
+	exclusiveMaximum *big.Rat
+	minimum          *big.Rat
+	exclusiveMinimum *big.Rat
+
+	maxLength *int
+	minLength *int
+	pattern   *regexp.Regexp
+
+	prefixItems []*schema
+	items       *schema
+	maxItems    *int
+	minItems    *int
+	uniqueItems bool
+
+	properties           map[string]*schema
+	patternProperties    []patternProperty
+	additionalProperties *schema
+	required             []string
+	maxProperties        *int
+	minProperties        *int
+
+	allOf []*schema
+	anyOf []*schema
+	oneOf []*schema
+	not   *schema
+	ref   *schema
+}
+
+type patternProperty struct {
+	pattern *regexp.Regexp
+	schema  *schema
+}
+
+var schemaTypes = []string{"null", "boolean", "object", "array", "number", "integer", "string"}
+
+// compileSchema parses and compiles a JSON Schema document.
+func compileSchema(document []byte) (*schema, error) {
+	root, err := decodeJSONValue(document)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	c := &schemaCompiler{root: root, compiled: map[string]*schema{}}
+	s, err := c.compile(root, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := c.checkCycles(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// checkCycles returns an error if a schema applies itself to the same value, through $ref and the in-place
+// applicators, without going through a property or an item. Validating such a schema would never end.
+func (c *schemaCompiler) checkCycles() error {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := map[*schema]int{}
+	var visit func(s *schema) bool
+	visit = func(s *schema) bool {
+		switch state[s] {
+		case visiting:
+			return true
+		case visited:
+			return false
+		}
+		state[s] = visiting
+		for _, next := range s.inPlace() {
+			if visit(next) {
+				return true
+			}
+		}
+		state[s] = visited
+		return false
+	}
+	pointers := make([]string, 0, len(c.compiled))
+	for pointer := range c.compiled {
+		pointers = append(pointers, pointer)
+	}
+	slices.Sort(pointers)
+	for _, pointer := range pointers {
+		if visit(c.compiled[pointer]) {
+			return fmt.Errorf("schema at %q references itself without consuming any input", pointer)
+		}
+	}
+	return nil
+}
+
+// inPlace returns the subschemas applied to the same value as s.
+func (s *schema) inPlace() []*schema {
+	var schemas []*schema
+	if s.ref != nil {
+		schemas = append(schemas, s.ref)
+	}
+	if s.not != nil {
+		schemas = append(schemas, s.not)
+	}
+	schemas = append(schemas, s.allOf...)
+	schemas = append(schemas, s.anyOf...)
+	return append(schemas, s.oneOf...)
+}
+
+type schemaCompiler struct {
+	root interface{}
+	// compiled holds the schemas compiled so far by their JSON pointer in the document, so that recursive
+	// references resolve to the schema being compiled.
+	compiled map[string]*schema
+}
+
+func (c *schemaCompiler) compile(node interface{}, pointer string) (*schema, error) {
+	if s, ok := c.compiled[pointer]; ok {
+		return s, nil
+	}
+	s := &schema{}
+	c.compiled[pointer] = s
+	if allow, ok := node.(bool); ok {
+		s.allow = &allow
+		return s, nil
+	}
+	object, ok := node.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("schema at %q must be an object or a boolean", pointer)
+	}
+	for keyword, value := range object {
+		if err := c.compileKeyword(s, keyword, value, pointer+"/"+escapePointerToken(keyword)); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func (c *schemaCompiler) compileKeyword(s *schema, keyword string, value interface{}, pointer string) error {
+	var err error
+	switch keyword {
+	case "type":
+		switch v := value.(type) {
+		case string:
+			s.types = []string{v}
+		case []interface{}:
+			for _, t := range v {
+				name, ok := t.(string)
+				if !ok {
+					return fmt.Errorf("%s must only hold strings", pointer)
+				}
+				s.types = append(s.types, name)
+			}
+		default:
+			return fmt.Errorf("%s must be a string or an array", pointer)
+		}
+		for _, t := range s.types {
+			if !slices.Contains(schemaTypes, t) {
+				return fmt.Errorf("%s holds the unknown type %q", pointer, t)
+			}
+		}
+	case "enum":
+		values, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s must be an array", pointer)
+		}
+		s.enum = values
+	case "const":
+		s.constant = &value
+	case "multipleOf":
+		s.multipleOf, err = schemaNumber(value, pointer)
+		if err == nil && s.multipleOf.Sign() <= 0 {
+			err = fmt.Errorf("%s must be strictly positive", pointer)
+		}
+	case "maximum":
+		s.maximum, err = schemaNumber(value, pointer)
+	case "exclusiveMaximum":
+		s.exclusiveMaximum, err = schemaNumber(value, pointer)
+	case "minimum":
+		s.minimum, err = schemaNumber(value, pointer)
+	case "exclusiveMinimum":
+		s.exclusiveMinimum, err = schemaNumber(value, pointer)
+	case "maxLength":
+		s.maxLength, err = schemaCount(value, pointer)
+	case "minLength":
+		s.minLength, err = schemaCount(value, pointer)
+	case "maxItems":
+		s.maxItems, err = schemaCount(value, pointer)
+	case "minItems":
+		s.minItems, err = schemaCount(value, pointer)
+	case "maxProperties":
+		s.maxProperties, err = schemaCount(value, pointer)
+	case "minProperties":
+		s.minProperties, err = schemaCount(value, pointer)
+	case "pattern":
+		s.pattern, err = schemaPattern(value, pointer)
+	case "uniqueItems":
+		unique, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("%s must be a boolean", pointer)
+		}
+		s.uniqueItems = unique
+	case "required":
+		values, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s must be an array", pointer)
+		}
+		for _, v := range values {
+			name, ok := v.(string)
+			if !ok {
+				return fmt.Errorf("%s must only hold strings", pointer)
+			}
+			s.required = append(s.required, name)
+		}
+	case "items":
+		s.items, err = c.compile(value, pointer)
+	case "additionalProperties":
+		s.additionalProperties, err = c.compile(value, pointer)
+	case "not":
+		s.not, err = c.compile(value, pointer)
+	case "prefixItems":
+		s.prefixItems, err = c.compileList(value, pointer)
+	case "allOf":
+		s.allOf, err = c.compileList(value, pointer)
+	case "anyOf":
+		s.anyOf, err = c.compileList(value, pointer)
+	case "oneOf":
+		s.oneOf, err = c.compileList(value, pointer)
+	case "properties", "patternProperties":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s must be an object", pointer)
+		}
+		if keyword == "properties" {
+			s.properties = map[string]*schema{}
+		}
+		for name, property := range object {
+			compiled, err := c.compile(property, pointer+"/"+escapePointerToken(name))
+			if err != nil {
+				return err
+			}
+			if keyword == "properties" {
+				s.properties[name] = compiled
+				continue
+			}
+			pattern, err := schemaPattern(name, pointer)
+			if err != nil {
+				return err
+			}
+			s.patternProperties = append(s.patternProperties, patternProperty{pattern: pattern, schema: compiled})
+		}
+	case "$ref":
+		ref, ok := value.(string)
+		if !ok || !strings.HasPrefix(ref, "#") {
+			return fmt.Errorf("%s must be a reference within the schema, e.g. #/$defs/name", pointer)
+		}
+		target, err := resolvePointer(c.root, strings.TrimPrefix(ref, "#"))
+		if err != nil {
+			return fmt.Errorf("%s: %w", pointer, err)
+		}
+		s.ref, err = c.compile(target, strings.TrimPrefix(ref, "#"))
+		return err
+	}
+	return err
+}
+
+func (c *schemaCompiler) compileList(value interface{}, pointer string) ([]*schema, error) {
+	values, ok := value.([]interface{})
+	if !ok || len(values) == 0 {
+		return nil, fmt.Errorf("%s must be a non empty array", pointer)
+	}
+	schemas := make([]*schema, 0, len(values))
+	for i, v := range values {
+		compiled, err := c.compile(v, pointer+"/"+strconv.Itoa(i))
+		if err != nil {
+			return nil, err
+		}
+		schemas = append(schemas, compiled)
+	}
+	return schemas, nil
+}
+
+func schemaNumber(value interface{}, pointer string) (*big.Rat, error) {
+	n, ok := value.(json.Number)
+	if !ok {
+		return nil, fmt.Errorf("%s must be a number", pointer)
+	}
+	r, ok := numberValue(n)
+	if !ok {
+		return nil, fmt.Errorf("%s is out of the supported range", pointer)
+	}
+	return r, nil
+}
+
+func schemaCount(value interface{}, pointer string) (*int, error) {
+	n, ok := value.(json.Number)
+	if !ok {
+		return nil, fmt.Errorf("%s must be a non negative integer", pointer)
+	}
+	count, err := strconv.Atoi(n.String())
+	if err != nil || count < 0 {
+		return nil, fmt.Errorf("%s must be a non negative integer", pointer)
+	}
+	return &count, nil
+}
+
+func schemaPattern(value interface{}, pointer string) (*regexp.Regexp, error) {
+	expr, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("%s must be a string", pointer)
+	}
+	pattern, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", pointer, err)
+	}
+	return pattern, nil
+}
+
+// resolvePointer returns the value at pointer (RFC 6901) within document.
+func resolvePointer(document interface{}, pointer string) (interface{}, error) {
+	if pointer == "" {
+		return document, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q", pointer)
+	}
+	node := document
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		switch v := node.(type) {
+		case map[string]interface{}:
+			next, ok := v[token]
+			if !ok {
+				return nil, fmt.Errorf("JSON pointer %q does not resolve", pointer)
+			}
+			node = next
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, fmt.Errorf("JSON pointer %q does not resolve", pointer)
+			}
+			node = v[i]
+		default:
+			return nil, fmt.Errorf("JSON pointer %q does not resolve", pointer)
+		}
+	}
+	return node, nil
+}
+
+func escapePointerToken(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+// decodeJSONValue decodes a JSON document keeping numbers as json.Number, so that they are compared exactly.
+func decodeJSONValue(document []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(document))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("unexpected data after the JSON value")
+	}
+	return value, nil
+}
+
+// Numbers are compared exactly as rationals, whose size grows with the number of digits and the exponent. The
+// numbers beyond these bounds, which no go numeric type holds anyway, are refused rather than parsed.
+const (
+	maxNumberLength   = 400
+	maxNumberExponent = 400
+)
+
+// numberValue returns the exact value of n, or false if n is beyond the supported range.
+func numberValue(n json.Number) (*big.Rat, bool) {
+	text := n.String()
+	if len(text) > maxNumberLength {
+		return nil, false
+	}
+	if i := strings.IndexAny(text, "eE"); i >= 0 {
+		exponent, err := strconv.Atoi(text[i+1:])
+		if err != nil || exponent > maxNumberExponent || exponent < -maxNumberExponent {
+			return nil, false
+		}
+	}
+	return new(big.Rat).SetString(text)
+}
+
+// validateParams returns every violation of the schema by the raw parameters of a request, nil when absent.
+func validateParams(s *schema, params json.RawMessage) []SchemaViolation {
+	var value interface{}
+	if len(params) > 0 {
+		var err error
+		if value, err = decodeJSONValue(params); err != nil {
+			return []SchemaViolation{{Keyword: "type", Message: err.Error()}}
+		}
+	}
+	return s.validate(value, "")
+}
+
+// validate returns every violation of the schema by value, which is located at pointer.
+func (s *schema) validate(value interface{}, pointer string) []SchemaViolation {
+	if s.allow != nil {
+		if *s.allow {
+			return nil
+		}
+		return []SchemaViolation{{Pointer: pointer, Keyword: "false", Message: "Value is not allowed"}}
+	}
+	var violations []SchemaViolation
+	violate := func(keyword string, format string, args ...interface{}) {
+		violations = append(violations, SchemaViolation{Pointer: pointer, Keyword: keyword, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if s.ref != nil {
+		violations = append(violations, s.ref.validateChild("$ref", value, pointer)...)
+	}
+	if len(s.types) > 0 && !slices.ContainsFunc(s.types, func(t string) bool { return hasType(value, t) }) {
+		violate("type", "Expected %s but got %s", strings.Join(s.types, " or "), typeName(value))
+	}
+	if s.enum != nil && !slices.ContainsFunc(s.enum, func(v interface{}) bool { return jsonEqual(v, value) }) {
+		violate("enum", "Value is not one of the allowed values")
+	}
+	if s.constant != nil && !jsonEqual(*s.constant, value) {
+		violate("const", "Value is not the allowed value")
+	}
+
+	switch v := value.(type) {
+	case json.Number:
+		if s.multipleOf == nil && s.maximum == nil && s.exclusiveMaximum == nil && s.minimum == nil && s.exclusiveMinimum == nil {
+			break
+		}
+		n, ok := numberValue(v)
+		if !ok {
+			violate("type", "Number is out of the supported range")
+			break
+		}
+		if s.multipleOf != nil && !new(big.Rat).Quo(n, s.multipleOf).IsInt() {
+			violate("multipleOf", "Expected a multiple of %s", s.multipleOf.RatString())
+		}
+		if s.maximum != nil && n.Cmp(s.maximum) > 0 {
+			violate("maximum", "Expected at most %s", s.maximum.RatString())
+		}
+		if s.exclusiveMaximum != nil && n.Cmp(s.exclusiveMaximum) >= 0 {
+			violate("exclusiveMaximum", "Expected less than %s", s.exclusiveMaximum.RatString())
+		}
+		if s.minimum != nil && n.Cmp(s.minimum) < 0 {
+			violate("minimum", "Expected at least %s", s.minimum.RatString())
+		}
+		if s.exclusiveMinimum != nil && n.Cmp(s.exclusiveMinimum) <= 0 {
+			violate("exclusiveMinimum", "Expected more than %s", s.exclusiveMinimum.RatString())
+		}
+	case string:
+		length := utf8.RuneCountInString(v)
+		if s.maxLength != nil && length > *s.maxLength {
+			violate("maxLength", "Expected at most %d characters", *s.maxLength)
+		}
+		if s.minLength != nil && length < *s.minLength {
+			violate("minLength", "Expected at least %d characters", *s.minLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			violate("pattern", "Expected to match %s", s.pattern.String())
+		}
+	case []interface{}:
+		for i, item := range v {
+			itemPointer := pointer + "/" + strconv.Itoa(i)
+			switch {
+			case i < len(s.prefixItems):
+				violations = append(violations, s.prefixItems[i].validateChild("prefixItems", item, itemPointer)...)
+			case s.items != nil:
+				violations = append(violations, s.items.validateChild("items", item, itemPointer)...)
+			}
+		}
+		if s.maxItems != nil && len(v) > *s.maxItems {
+			violate("maxItems", "Expected at most %d items", *s.maxItems)
+		}
+		if s.minItems != nil && len(v) < *s.minItems {
+			violate("minItems", "Expected at least %d items", *s.minItems)
+		}
+		if s.uniqueItems {
+		unique:
+			for i := range v {
+				for j := i + 1; j < len(v); j++ {
+					if jsonEqual(v[i], v[j]) {
+						violate("uniqueItems", "Items %d and %d are equal", i, j)
+						break unique
+					}
+				}
+			}
+		}
+	case map[string]interface{}:
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			propertyPointer := pointer + "/" + escapePointerToken(name)
+			matched := false
+			if property, ok := s.properties[name]; ok {
+				matched = true
+				violations = append(violations, property.validateChild("properties", v[name], propertyPointer)...)
+			}
+			for _, p := range s.patternProperties {
+				if p.pattern.MatchString(name) {
+					matched = true
+					violations = append(violations, p.schema.validateChild("patternProperties", v[name], propertyPointer)...)
+				}
+			}
+			if !matched && s.additionalProperties != nil {
+				violations = append(violations, s.additionalProperties.validateChild("additionalProperties", v[name], propertyPointer)...)
+			}
+		}
+		for _, name := range s.required {
+			if _, ok := v[name]; !ok {
+				violate("required", "Missing required property %q", name)
+			}
+		}
+		if s.maxProperties != nil && len(v) > *s.maxProperties {
+			violate("maxProperties", "Expected at most %d properties", *s.maxProperties)
+		}
+		if s.minProperties != nil && len(v) < *s.minProperties {
+			violate("minProperties", "Expected at least %d properties", *s.minProperties)
+		}
+	}
+
+	for _, sub := range s.allOf {
+		violations = append(violations, sub.validateChild("allOf", value, pointer)...)
+	}
+	if s.anyOf != nil && !slices.ContainsFunc(s.anyOf, func(sub *schema) bool { return sub.valid(value) }) {
+		violate("anyOf", "Value does not match any of the allowed schemas")
+	}
+	if s.oneOf != nil {
+		matches := 0
+		for _, sub := range s.oneOf {
+			if sub.valid(value) {
+				matches++
+			}
+		}
+		if matches != 1 {
+			violate("oneOf", "Value matches %d of the schemas instead of exactly one", matches)
+		}
+	}
+	if s.not != nil && s.not.valid(value) {
+		violate("not", "Value matches a disallowed schema")
+	}
+	return violations
+}
+
+// validateChild validates value against s, a subschema of keyword. The violation of a false subschema is reported
+// as a violation of keyword.
+func (s *schema) validateChild(keyword string, value interface{}, pointer string) []SchemaViolation {
+	if s.allow != nil && !*s.allow {
+		return []SchemaViolation{{Pointer: pointer, Keyword: keyword, Message: "Value is not allowed"}}
+	}
+	return s.validate(value, pointer)
+}
+
+func (s *schema) valid(value interface{}) bool {
+	return len(s.validate(value, "")) == 0
+}
+
+func hasType(value interface{}, t string) bool {
+	switch v := value.(type) {
+	case nil:
+		return t == "null"
+	case bool:
+		return t == "boolean"
+	case string:
+		return t == "string"
+	case []interface{}:
+		return t == "array"
+	case map[string]interface{}:
+		return t == "object"
+	case json.Number:
+		if t == "number" {
+			return true
+		}
+		n, ok := numberValue(v)
+		return t == "integer" && ok && n.IsInt()
+	}
+	return false
+}
+
+func typeName(value interface{}) string {
+	for _, t := range []string{"null", "boolean", "string", "array", "object", "integer", "number"} {
+		if hasType(value, t) {
+			return t
+		}
+	}
+	return "unknown"
+}
+
+// jsonEqual reports whether two decoded JSON values are equal, comparing numbers by value.
+func jsonEqual(a interface{}, b interface{}) bool {
+	switch x := a.(type) {
+	case json.Number:
+		y, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		m, mOk := numberValue(x)
+		n, nOk := numberValue(y)
+		if !mOk || !nOk {
+			return x == y
+		}
+		return m.Cmp(n) == 0
+	case []interface{}:
+		y, ok := b.([]interface{})
+		return ok && slices.EqualFunc(x, y, jsonEqual)
+	case map[string]interface{}:
+		y, ok := b.(map[string]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for name, v := range x {
+			w, ok := y[name]
+			if !ok || !jsonEqual(v, w) {
+				return false
+			}
+		}
+		return true
+	}
+	return a == b
+}
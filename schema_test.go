@@ -0,0 +1,129 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const userSchema = `{
+	"type": "object",
+	"required": ["name", "age"],
+	"properties": {
+		"name": {"type": "string", "minLength": 1, "pattern": "^[a-z]+$"},
+		"age": {"type": "integer", "minimum": 0, "exclusiveMaximum": 150},
+		"tags": {"type": "array", "items": {"type": "string"}, "uniqueItems": true, "maxItems": 3},
+		"role": {"enum": ["admin", "user"]},
+		"address": {"$ref": "#/$defs/address"}
+	},
+	"additionalProperties": false,
+	"$defs": {
+		"address": {"type": "object", "required": ["city"], "properties": {"city": {"type": "string"}}}
+	}
+}`
+
+func TestParamsSchema(t *testing.T) {
+	executed := false
+	s := New(WithParamsSchema("user.create", json.RawMessage(userSchema)))
+	s.Register(NewTypedHandler("user.create", func(ctx context.Context, headers http.Header, id ID, params map[string]interface{}) (string, error) {
+		executed = true
+		return "created", nil
+	}))
+
+	recorder := serve(t, s, `{"jsonrpc":"2.0","method":"user.create","id":1,"params":{"name":"ada","age":36,"tags":["a","b"],"role":"admin","address":{"city":"London"}}}`)
+	require.Equal(t, `{"jsonrpc":"2.0","result":"created","id":1}`, recorder.Body.String())
+	require.True(t, executed)
+
+	executed = false
+	recorder = serve(t, s, `{"jsonrpc":"2.0","method":"user.create","id":2,"params":{"name":"Ada","age":36.5,"tags":["a","a"],"role":"root","address":{},"extra":1}}`)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+	require.False(t, executed)
+	var response struct {
+		Error struct {
+			Code int `json:"code"`
+			Data struct {
+				Violations []SchemaViolation `json:"violations"`
+			} `json:"data"`
+		} `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	require.Equal(t, -32602, response.Error.Code)
+	require.Equal(t, []SchemaViolation{
+		{Pointer: "/address", Keyword: "required", Message: `Missing required property "city"`},
+		{Pointer: "/age", Keyword: "type", Message: "Expected integer but got number"},
+		{Pointer: "/extra", Keyword: "additionalProperties", Message: "Value is not allowed"},
+		{Pointer: "/name", Keyword: "pattern", Message: "Expected to match ^[a-z]+$"},
+		{Pointer: "/role", Keyword: "enum", Message: "Value is not one of the allowed values"},
+		{Pointer: "/tags", Keyword: "uniqueItems", Message: "Items 0 and 1 are equal"},
+	}, response.Error.Data.Violations)
+
+	recorder = serve(t, s, `{"jsonrpc":"2.0","method":"user.create","id":3,"params":[1]}`)
+	require.JSONEq(t, `{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params","data":{"violations":[
+		{"pointer":"","keyword":"type","message":"Expected object but got array"}
+	]}},"id":3}`, recorder.Body.String())
+
+	require.Panics(t, func() { New(WithParamsSchema("user.create", json.RawMessage(`{"type":"text"}`))) })
+	require.Panics(t, func() { New(WithParamsSchema("user.create", json.RawMessage(`{"$ref":"#/$defs/missing"}`))) })
+	require.Panics(t, func() { New(WithParamsSchema("user.create", json.RawMessage(`{"maximum":1e10000000}`))) })
+	for _, cycle := range []string{
+		`{"$ref":"#"}`,
+		`{"$defs":{"a":{"$ref":"#/$defs/a"}},"$ref":"#/$defs/a"}`,
+		`{"$defs":{"a":{"allOf":[{"$ref":"#/$defs/b"}]},"b":{"not":{"$ref":"#/$defs/a"}}},"$ref":"#/$defs/a"}`,
+		`{"properties":{"a":{"anyOf":[{"$ref":"#/properties/a"}]}}}`,
+	} {
+		require.Panics(t, func() { New(WithParamsSchema("user.create", json.RawMessage(cycle))) }, cycle)
+	}
+	// Recursion through a property consumes input and is allowed.
+	require.NotPanics(t, func() {
+		New(WithParamsSchema("user.create", json.RawMessage(`{"type":"object","properties":{"next":{"$ref":"#"}}}`)))
+	})
+}
+
+func TestSchemaKeywords(t *testing.T) {
+	tests := []struct {
+		schema   string
+		value    string
+		keywords []string
+	}{
+		{schema: `true`, value: `{"a":1}`},
+		{schema: `{"type":["string","null"]}`, value: `null`},
+		{schema: `{"type":"integer"}`, value: `1.0`},
+		{schema: `{"type":"integer"}`, value: `"1"`, keywords: []string{"type"}},
+		{schema: `{"const":{"a":[1,2]}}`, value: `{"a":[1.0,2]}`},
+		{schema: `{"multipleOf":0.1}`, value: `0.3`},
+		{schema: `{"multipleOf":2}`, value: `3`, keywords: []string{"multipleOf"}},
+		{schema: `{"maximum":3,"exclusiveMinimum":1}`, value: `1`, keywords: []string{"exclusiveMinimum"}},
+		{schema: `{"maxLength":2}`, value: `"héé"`, keywords: []string{"maxLength"}},
+		{schema: `{"prefixItems":[{"type":"string"}],"items":{"type":"integer"},"minItems":2}`, value: `["a",1,2]`},
+		{schema: `{"prefixItems":[{"type":"string"}],"items":{"type":"integer"}}`, value: `[1,"a"]`, keywords: []string{"type", "type"}},
+		{schema: `{"patternProperties":{"^x-":{"type":"string"}},"additionalProperties":{"type":"integer"}}`, value: `{"x-a":"b","c":1}`},
+		{schema: `{"minProperties":1,"maxProperties":1}`, value: `{}`, keywords: []string{"minProperties"}},
+		{schema: `{"allOf":[{"minimum":1},{"maximum":0}]}`, value: `2`, keywords: []string{"maximum"}},
+		{schema: `{"anyOf":[{"type":"string"},{"type":"integer"}]}`, value: `true`, keywords: []string{"anyOf"}},
+		{schema: `{"oneOf":[{"type":"number"},{"type":"integer"}]}`, value: `1`, keywords: []string{"oneOf"}},
+		{schema: `{"not":{"type":"null"}}`, value: `null`, keywords: []string{"not"}},
+		{schema: `{"$defs":{"node":{"type":"object","properties":{"next":{"$ref":"#/$defs/node"}}}},"$ref":"#/$defs/node"}`, value: `{"next":{"next":1}}`, keywords: []string{"type"}},
+		{schema: `false`, value: `1`, keywords: []string{"false"}},
+		{schema: `{"prefixItems":[true,false]}`, value: `[1,2]`, keywords: []string{"prefixItems"}},
+		{schema: `{"format":"email","title":"ignored"}`, value: `"not an email"`},
+		{schema: `{"items":{"type":"number","maximum":10}}`, value: `[1e10000000,11,1e400,1e-10000000]`, keywords: []string{"type", "maximum", "maximum", "type"}},
+		{schema: `{"minimum":0}`, value: `1` + strings.Repeat("0", 500), keywords: []string{"type"}},
+		{schema: `{"type":"integer"}`, value: `1e999999`, keywords: []string{"type"}},
+		{schema: `{"type":"number"}`, value: `1e999999`},
+	}
+	for _, test := range tests {
+		s, err := compileSchema([]byte(test.schema))
+		require.NoError(t, err, test.schema)
+		value, err := decodeJSONValue([]byte(test.value))
+		require.NoError(t, err)
+		var keywords []string
+		for _, violation := range s.validate(value, "") {
+			keywords = append(keywords, violation.Keyword)
+		}
+		require.Equal(t, test.keywords, keywords, "%s with %s", test.schema, test.value)
+	}
+}
@@ -1,14 +1,21 @@
 package jsonrpc
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"golang.org/x/sync/errgroup"
 	"io"
 	"log/slog"
+	"net"
 	"net/http"
+	"runtime/debug"
 	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 )
 
 // New returns a json-rpc server with rational defaults.
@@ -21,16 +28,40 @@ func New(options ...Option) Server {
 		option(opts)
 	}
 	handler := &jsonRPCServer{
-		mux:     mux,
-		opts:    opts,
-		methods: make(map[string]RPCHandler),
+		mux:       mux,
+		opts:      opts,
+		methods:   make(map[string]RPCHandler),
+		listeners: make(map[net.Listener]struct{}),
+		shutdown:  make(chan struct{}),
+		schemas:   make(map[string]*schema),
+	}
+	for method, document := range opts.paramsSchemas {
+		compiled, err := compileSchema(document)
+		if err != nil {
+			panic("invalid params schema for method " + method + ": " + err.Error())
+		}
+		handler.schemas[method] = compiled
+	}
+	if !strings.HasPrefix(opts.rpcPath, "/") {
+		panic("rpc path " + strconv.Quote(opts.rpcPath) + " must start with /")
+	}
+	if rpcPath := strings.TrimSuffix(opts.rpcPath, "/"); rpcPath == "" {
+		mux.HandleFunc("/", handler.serveRPC)
+	} else {
+		mux.HandleFunc(rpcPath, handler.serveRPC)
+		mux.HandleFunc(rpcPath+"/", handler.serveRPC)
+	}
+	if !opts.healthEndpoints {
+		return handler
 	}
-	mux.Handle("/rpc", handler)
-	mux.Handle("/rpc/", handler)
 	mux.HandleFunc("/health", func(writer http.ResponseWriter, request *http.Request) {
 		writer.WriteHeader(http.StatusOK)
 	})
 	mux.HandleFunc("/readiness", func(writer http.ResponseWriter, request *http.Request) {
+		if handler.draining.Load() {
+			writer.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
 		writer.WriteHeader(http.StatusOK)
 	})
 	mux.HandleFunc("/liveliness", func(writer http.ResponseWriter, request *http.Request) {
@@ -42,28 +73,72 @@ func New(options ...Option) Server {
 type RPCHandler interface {
 	// MethodName return the name of the RPC server
 	MethodName() string
-	// Execute computes the result of the rpc call using the provided parameters
-	Execute(ctx context.Context, headers http.Header, id *string, params interface{}) (interface{}, error)
+	// Execute computes the result of the rpc call using the provided parameters.
+	// The parameters are passed as a json.RawMessage, or nil when the request has none.
+	Execute(ctx context.Context, headers http.Header, id ID, params interface{}) (interface{}, error)
 	// ParametersValid returns true if the provided parameters can be used with this method
 	// The details returned can be used to explain why the parameters are not valid.
 	ParametersValid(ctx context.Context, params interface{}) ([]Detail, bool)
 }
+
+// Server is also a http.Handler serving the rpc endpoint and, unless disabled, the health endpoints.
+// It can be mounted in an existing router instead of calling Start.
 type Server interface {
+	http.Handler
 	Register(handler RPCHandler)
+	RegisterService(prefix string, svc interface{})
 	Start(port int) error
+	// ServeStream serves rpc requests read from codec until the end of the stream, e.g. over stdin and stdout.
+	ServeStream(ctx context.Context, codec Codec) error
+	// Serve serves rpc requests on every connection accepted by listener, using the framing of newCodec.
+	Serve(listener net.Listener, newCodec CodecFunc) error
+	// Shutdown stops accepting new requests and waits for the ones in flight to complete or for ctx to expire.
+	Shutdown(ctx context.Context) error
 }
 
 type jsonRPCServer struct {
 	opts    *serverOpts
 	mux     *http.ServeMux
 	methods map[string]RPCHandler
+	// schemas holds the compiled params schemas by method name.
+	schemas map[string]*schema
+
+	lock        sync.Mutex
+	httpServers []*http.Server
+	listeners   map[net.Listener]struct{}
+	draining    atomic.Bool
+	// shutdown is closed when Shutdown is called.
+	shutdown chan struct{}
+	// active tracks the persistent connections and streams being served.
+	active sync.WaitGroup
 }
 
+// Start serves the rpc and health endpoints over HTTP on port, or HTTPS when TLS is configured.
+// It blocks until the server fails or is stopped with Shutdown, in which case it returns nil.
 func (j *jsonRPCServer) Start(port int) error {
-	return http.ListenAndServe(":"+strconv.Itoa(port), j.mux)
+	tlsConfig, err := j.opts.tlsConfig()
+	if err != nil {
+		return err
+	}
+	server := &http.Server{Addr: ":" + strconv.Itoa(port), Handler: j, TLSConfig: tlsConfig}
+	if !j.track(func() { j.httpServers = append(j.httpServers, server) }) {
+		return http.ErrServerClosed
+	}
+	if tlsConfig != nil {
+		err = server.ListenAndServeTLS("", "")
+	} else {
+		err = server.ListenAndServe()
+	}
+	if !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
 }
 
 func (j *jsonRPCServer) Register(handler RPCHandler) {
+	if strings.HasPrefix(handler.MethodName(), "rpc.") {
+		panic("method names starting with rpc. are reserved")
+	}
 	if _, ok := j.methods[handler.MethodName()]; ok {
 		panic("method all registered")
 	}
@@ -71,117 +146,283 @@ func (j *jsonRPCServer) Register(handler RPCHandler) {
 }
 
 func (j *jsonRPCServer) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	j.mux.ServeHTTP(writer, request)
+}
+
+// serveRPC serves the rpc endpoint. It handles POST requests as well as websocket upgrades,
+// and answers other GET requests with the OpenRPC document of the server. Requests are tracked so that Shutdown
+// waits for them even when the server is mounted in another mux, and refused with a 503 once it has started.
+func (j *jsonRPCServer) serveRPC(writer http.ResponseWriter, request *http.Request) {
+	if !j.track(func() { j.active.Add(1) }) {
+		_ = request.Body.Close()
+		writer.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	defer j.active.Done()
 	defer func() {
 		err := request.Body.Close()
 		if err != nil {
 			slog.Error("Failed to close request body")
 		}
 	}()
-	ctx := ContextWithParams(request.Context(), LogOnlyParam("method", request.Method))
+	ctx := ContextWithParams(request.Context(), append(peerParams(request.TLS), LogOnlyParam("method", request.Method))...)
+	if isWebSocketUpgrade(request) {
+		j.serveWebSocket(ctx, writer, request)
+		return
+	}
+	if request.Method == http.MethodGet {
+		j.serveDiscoveryDocument(writer)
+		return
+	}
 	if request.Method != http.MethodPost {
 		writer.WriteHeader(http.StatusMethodNotAllowed)
-		_, _ = writer.Write(NewMethodNotFoundError(NewDetail("rationale", "All RPC request should be made with a POST method.")).JSONRPCBytes())
+		_, _ = writer.Write(NewMethodNotFoundError(nil, NewDetail("rationale", "All RPC request should be made with a POST method.")).JSONRPCBytes())
 		return
 	}
 
-	requestBytes, err := io.ReadAll(io.LimitReader(request.Body, j.opts.maxRequestSize))
+	status, responseBytes := j.handleReader(ctx, request.Header, io.LimitReader(request.Body, j.opts.maxRequestSize))
+	writer.WriteHeader(status)
+	if responseBytes == nil {
+		return
+	}
+	if _, err := writer.Write(responseBytes); err != nil {
+		slog.Error("Failed to write response body")
+	}
+}
+
+// handleMessage executes the single or batch request encoded in message and returns the encoded response
+// along with the matching http status code. The response is nil when nothing should be sent back,
+// which is the case for notifications.
+func (j *jsonRPCServer) handleMessage(ctx context.Context, headers http.Header, message []byte) (int, []byte) {
+	return j.handleReader(ctx, headers, bytes.NewReader(message))
+}
+
+// handleReader is handleMessage for a request read from r. The request is decoded in a single pass:
+// the first non whitespace byte tells a batch from a single request, and the params are kept as raw JSON.
+func (j *jsonRPCServer) handleReader(ctx context.Context, headers http.Header, r io.Reader) (int, []byte) {
+	// The buffer is only needed to peek the first byte: the larger reads of the decoder bypass it.
+	reader := bufio.NewReaderSize(r, 16)
+	first, err := peekNonSpace(reader)
 	if err != nil {
-		writer.WriteHeader(http.StatusBadRequest)
-		if _, err := writer.Write(NewParseError(NewDetail("rationale", "Failed to read request body")).JSONRPCBytes()); err != nil {
-			slog.Error("Failed to write response body")
+		return http.StatusBadRequest, NewParseError(NewDetail("rationale", "Failed to parse valid json from request body")).JSONRPCBytes()
+	}
+	decoder := json.NewDecoder(reader)
+	if first == '[' {
+		// The entries are decoded one by one so that a malformed entry does not fail the whole batch.
+		var entries []json.RawMessage
+		if err := decodeWhole(decoder, &entries); err != nil {
+			return http.StatusBadRequest, NewParseError(NewDetail("rationale", "Failed to parse valid json from request body")).JSONRPCBytes()
 		}
-		return
+		return j.handleBatchRequest(ctx, headers, entries)
 	}
-	var maybeBatchRequest BatchRequest
-	if err := json.Unmarshal(requestBytes, &maybeBatchRequest); err != nil {
-		var maybeSingleRequest Request
-		if err := json.Unmarshal(requestBytes, &maybeSingleRequest); err != nil {
-			writer.WriteHeader(http.StatusBadRequest)
-			if _, err := writer.Write(NewParseError(NewDetail("rationale", "Failed to parse valid json from request body")).JSONRPCBytes()); err != nil {
-				slog.Error("Failed to write response body")
-			}
-			return
+	var singleRequest Request
+	err = decodeWhole(decoder, &singleRequest)
+	if err != nil && !isInvalidRequest(err) {
+		return http.StatusBadRequest, NewParseError(NewDetail("rationale", "Failed to parse valid json from request body")).JSONRPCBytes()
+	}
+	if err == nil {
+		err = checkRequest(singleRequest)
+	}
+	if err != nil {
+		return http.StatusBadRequest, NewInvalidRequestError(singleRequest.ID, NewDetail("rationale", err.Error())).JSONRPCBytes()
+	}
+	return j.handleSingleRequest(ctx, headers, singleRequest)
+}
+
+// decodeBatchEntry decodes an entry of a batch. Entries that are not valid requests are reported as an invalid
+// request error, with the id of the entry when it can be found.
+func decodeBatchEntry(entry json.RawMessage) (Request, error) {
+	var request Request
+	err := json.Unmarshal(entry, &request)
+	if err == nil {
+		err = checkRequest(request)
+	}
+	if err == nil {
+		return request, nil
+	}
+	var withID struct {
+		ID json.RawMessage `json:"id"`
+	}
+	var id ID
+	if json.Unmarshal(entry, &withID) != nil || id.UnmarshalJSON(withID.ID) != nil {
+		id = nil
+	}
+	return Request{}, NewInvalidRequestError(id, NewDetail("rationale", err.Error()))
+}
+
+// isInvalidRequest reports whether err, returned while decoding a request, means that the JSON is valid but
+// does not hold a request object.
+func isInvalidRequest(err error) bool {
+	var typeErr *json.UnmarshalTypeError
+	return errors.As(err, &typeErr) || errors.Is(err, errInvalidID) || errors.Is(err, errEmptyID)
+}
+
+// checkRequest returns an error explaining why a decoded request object is not a valid request.
+func checkRequest(request Request) error {
+	if request.JSONRPC != "2.0" {
+		return errors.New("Only JSONRPC version 2 is supported")
+	}
+	if request.Method == "" {
+		return errors.New("The method of the request is missing")
+	}
+	return nil
+}
+
+// peekNonSpace skips the leading JSON whitespace of reader and returns the next byte without consuming it.
+func peekNonSpace(reader *bufio.Reader) (byte, error) {
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return 0, err
 		}
-		j.handleSingleRequest(ctx, writer, request, maybeSingleRequest)
-		return
+		switch b {
+		case ' ', '\t', '\n', '\r':
+			continue
+		}
+		return b, reader.UnreadByte()
+	}
+}
+
+// decodeWhole decodes the next value of decoder into v and makes sure nothing but whitespace follows it.
+// Values that are valid JSON but cannot be decoded into v are still read whole, so that trailing data is reported.
+func decodeWhole(decoder *json.Decoder, v interface{}) error {
+	err := decoder.Decode(v)
+	if err != nil && !isInvalidRequest(err) {
+		return err
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return errors.New("unexpected data after the JSON value")
 	}
-	j.handleBatchRequest(ctx, writer, request, maybeBatchRequest)
+	return err
 }
 
-func (j *jsonRPCServer) handleSingleRequest(ctx context.Context, writer http.ResponseWriter, request *http.Request, jsonRequest Request) {
-	response, err := j.routeRequest(ctx, request, jsonRequest)
+func (j *jsonRPCServer) handleSingleRequest(ctx context.Context, headers http.Header, jsonRequest Request) (int, []byte) {
+	response, err := j.routeRequest(ctx, headers, jsonRequest)
+	if jsonRequest.ID == nil {
+		return http.StatusNoContent, nil
+	}
 	if err != nil {
-		writer.WriteHeader(http.StatusBadRequest)
 		if gerr, ok := err.(ToJSONRPCBytes); ok {
-			if _, err := writer.Write(gerr.JSONRPCBytes()); err != nil {
-				slog.Error("Failed to write failed request response body")
-			}
-			return
+			return http.StatusBadRequest, gerr.JSONRPCBytes()
 		}
-		return
-	}
-	writer.WriteHeader(http.StatusOK)
-	if _, err := writer.Write(response.JSONRPCBytes()); err != nil {
-		slog.Error("Failed to write failed request response body on successful request")
+		return http.StatusBadRequest, nil
 	}
-	return
+	return http.StatusOK, response.JSONRPCBytes()
 }
 
-func (j *jsonRPCServer) handleBatchRequest(ctx context.Context, writer http.ResponseWriter, request *http.Request, batchJsonRequest BatchRequest) {
-	if len(batchJsonRequest) > j.opts.maxBatchSize {
-		writer.WriteHeader(http.StatusBadRequest)
-		if _, err := writer.Write(NewInvalidRequestError(nil, NewDetail("rationale", "Too many requests"), NewDetail("maxBatchSize", j.opts.maxBatchSize)).JSONRPCBytes()); err != nil {
-			slog.Error("Failed to write response body")
-		}
-		return
+// handleBatchRequest executes every entry of a batch. Entries that are not valid requests are answered with their own
+// invalid request error, while the other entries are executed as usual.
+func (j *jsonRPCServer) handleBatchRequest(ctx context.Context, headers http.Header, entries []json.RawMessage) (int, []byte) {
+	if len(entries) == 0 {
+		return http.StatusBadRequest, NewInvalidRequestError(nil, NewDetail("rationale", "Batches must hold at least one request")).JSONRPCBytes()
+	}
+	if len(entries) > j.opts.maxBatchSize {
+		return http.StatusBadRequest, NewInvalidRequestError(nil, NewDetail("rationale", "Too many requests"), NewDetail("maxBatchSize", j.opts.maxBatchSize)).JSONRPCBytes()
 	}
 	eg := errgroup.Group{}
-	eg.SetLimit(j.opts.batchRequestParallelism)
+	if j.opts.sequentialBatches {
+		// Go blocks until the previous request is done, so the requests are executed in order.
+		eg.SetLimit(1)
+	} else {
+		eg.SetLimit(j.opts.batchRequestParallelism)
+	}
 	lock := sync.Mutex{}
 	var responses []interface{}
-	for _, r := range batchJsonRequest {
+	// ordered holds the response of every entry at its index, nil for notifications, when responses are ordered.
+	var ordered []interface{}
+	if j.opts.orderedBatchResponses {
+		ordered = make([]interface{}, len(entries))
+	}
+	for i, entry := range entries {
 		eg.Go(func() error {
-			resp, err := j.routeRequest(ctx, request, r)
+			r, err := decodeBatchEntry(entry)
+			// Invalid entries are always answered, as they cannot be told apart from notifications.
+			answer := err != nil || r.ID != nil
+			var resp Response
+			if err == nil {
+				resp, err = j.routeRequest(ctx, headers, r)
+			}
+			if !answer {
+				return nil
+			}
+			var response interface{} = resp
+			if err != nil {
+				response = err
+			}
+			if ordered != nil {
+				ordered[i] = response
+				return nil
+			}
 			lock.Lock()
 			defer lock.Unlock()
-			if r.ID != nil {
-				if err == nil {
-					responses = append(responses, resp)
-				} else {
-					responses = append(responses, err)
-				}
-			}
+			responses = append(responses, response)
 			return nil
 		})
 	}
 	_ = eg.Wait()
-	writer.WriteHeader(http.StatusOK)
+	for _, response := range ordered {
+		if response != nil {
+			responses = append(responses, response)
+		}
+	}
+	if len(responses) == 0 {
+		// The batch only held notifications.
+		return http.StatusNoContent, nil
+	}
 
 	b, err := json.Marshal(responses)
-	if err == nil {
-		_, _ = writer.Write(b)
+	if err != nil {
+		slog.Error("Failed to marshal batch response", "error", err)
+		return http.StatusOK, nil
 	}
-	return
+	return http.StatusOK, b
 }
 
-func (j *jsonRPCServer) routeRequest(ctx context.Context, request *http.Request, rpcRequest Request) (_ Response, err error) {
+// handler returns the handler of method, including the reserved rpc.discover method.
+func (j *jsonRPCServer) handler(method string) (RPCHandler, bool) {
+	if method == DiscoverMethodName {
+		return &discoverHandler{server: j}, true
+	}
+	handler, ok := j.methods[method]
+	return handler, ok
+}
+
+func (j *jsonRPCServer) routeRequest(ctx context.Context, headers http.Header, rpcRequest Request) (_ Response, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("Recovered from panic in rpc handler", "log.type", "panic.v1", "method", rpcRequest.Method, "id", rpcRequest.ID.String(), "panic", r, "stack", string(debug.Stack()))
+			err = NewInternalError(rpcRequest.ID, NewDetail("rationale", "The handler panicked while executing the request"))
+		}
+	}()
 	if rpcRequest.JSONRPC != "2.0" {
 		return Response{}, NewInvalidRequestError(rpcRequest.ID, NewDetail("rationale", "Only JSONRPC version 2 is supported"))
 	}
-	handler, ok := j.methods[rpcRequest.Method]
-	if !ok {
-		return Response{}, NewMethodNotFoundError()
+	if _, ok := j.handler(rpcRequest.Method); !ok {
+		return Response{}, NewMethodNotFoundError(rpcRequest.ID)
 	}
 	slog.Info("Received request", "log.type", "request.v1", "method", rpcRequest.Method)
-	if details, ok := handler.ParametersValid(ctx, rpcRequest.Params); !ok {
-		return Response{}, NewInvalidRequestError(rpcRequest.ID, details...)
-	}
-	result, err := handler.Execute(ctx, request.Header, rpcRequest.ID, rpcRequest.Params)
+	invoke := chainInterceptors(j.opts.interceptors, func(ctx context.Context, headers http.Header, rpcRequest Request) (interface{}, error) {
+		// The handler is looked up again as interceptors may have rewritten the method.
+		handler, ok := j.handler(rpcRequest.Method)
+		if !ok {
+			return nil, NewMethodNotFoundError(rpcRequest.ID)
+		}
+		if s, ok := j.schemas[rpcRequest.Method]; ok {
+			if violations := validateParams(s, rpcRequest.Params); len(violations) > 0 {
+				return nil, NewInvalidParamsError(rpcRequest.ID, NewDetail("violations", violations))
+			}
+		}
+		if details, ok := handler.ParametersValid(ctx, rpcRequest.params()); !ok {
+			return nil, NewInvalidParamsError(rpcRequest.ID, details...)
+		}
+		return handler.Execute(ctx, headers, rpcRequest.ID, rpcRequest.params())
+	})
+	result, err := invoke(ctx, headers, rpcRequest)
 	if err != nil {
 		if _, ok := err.(ToJSONRPCBytes); ok {
 			return Response{}, err
 		}
-		return Response{}, FromStandardError(rpcRequest.ID, err)
+		return Response{}, NewInternalError(rpcRequest.ID, NewDetail("rationale", err.Error()))
 	}
 	return NewResponse(rpcRequest.ID, result), nil
 }
@@ -1,8 +1,240 @@
 package jsonrpc
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewServer(t *testing.T) {
 }
+
+func echo(ctx context.Context, headers http.Header, id ID, params string) (string, error) {
+	return params, nil
+}
+
+func serve(t *testing.T, s Server, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	s.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(body)))
+	return recorder
+}
+
+func TestRequestIDRoundTrip(t *testing.T) {
+	s := New()
+	s.Register(NewTypedHandler("echo", echo))
+
+	for _, id := range []string{`"abc"`, `1`, `-7`, `1.50`, `12345678901234567890`, `null`} {
+		recorder := serve(t, s, `{"jsonrpc":"2.0","method":"echo","id":`+id+`,"params":"hi"}`)
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Equal(t, `{"jsonrpc":"2.0","result":"hi","id":`+id+`}`, recorder.Body.String())
+	}
+
+	recorder := serve(t, s, `{"jsonrpc":"2.0","method":"missing","id":3}`)
+	require.JSONEq(t, `{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found","data":{}},"id":3}`, recorder.Body.String())
+}
+
+func TestID(t *testing.T) {
+	require.Equal(t, "abc", StringID("abc").String())
+	require.True(t, StringID("1").IsString())
+	require.True(t, IntID(1).IsNumber())
+	require.True(t, NullID().IsNull())
+	require.True(t, ID(nil).IsNull())
+	require.False(t, IntID(1).Equal(StringID("1")))
+
+	var id ID
+	require.Error(t, id.UnmarshalJSON([]byte(`{"a":1}`)))
+}
+
+func fail(ctx context.Context, headers http.Header, id ID, params string) (string, error) {
+	return "", errors.New(params)
+}
+
+func TestErrorCodes(t *testing.T) {
+	s := New()
+	s.Register(NewTypedHandler("fail", fail))
+	s.Register(NewTypedHandler("echo", echo))
+
+	recorder := serve(t, s, `{"jsonrpc":"2.0","method":"fail","id":1,"params":"boom"}`)
+	require.JSONEq(t, `{"jsonrpc":"2.0","error":{"code":-32603,"message":"Internal error","data":{"rationale":"boom"}},"id":1}`, recorder.Body.String())
+
+	recorder = serve(t, s, `{"jsonrpc":"2.0","method":"echo","id":2,"params":[1]}`)
+	require.Contains(t, recorder.Body.String(), `"code":-32602`)
+
+	recorder = serve(t, s, `{"jsonrpc":"1.0","method":"echo","id":3,"params":"hi"}`)
+	require.Contains(t, recorder.Body.String(), `"code":-32600`)
+
+	require.Equal(t, -32001, NewServerError(nil, "Busy", -32001).RpcError.Code)
+	require.Panics(t, func() { NewServerError(nil, "Busy", -31999) })
+}
+
+func explode(ctx context.Context, headers http.Header, id ID, params string) (string, error) {
+	panic(params)
+}
+
+func TestPanicRecovery(t *testing.T) {
+	s := New()
+	s.Register(NewTypedHandler("explode", explode))
+	s.Register(NewTypedHandler("echo", echo))
+
+	recorder := serve(t, s, `{"jsonrpc":"2.0","method":"explode","id":1,"params":"boom"}`)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+	require.Contains(t, recorder.Body.String(), `"code":-32603`)
+
+	recorder = serve(t, s, `[{"jsonrpc":"2.0","method":"explode","id":1,"params":"boom"},{"jsonrpc":"2.0","method":"echo","id":2,"params":"hi"}]`)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Contains(t, recorder.Body.String(), `"code":-32603`)
+	require.Contains(t, recorder.Body.String(), `"result":"hi"`)
+}
+
+func TestMountInRouter(t *testing.T) {
+	s := New(WithRPCPath("/"), WithHealthEndpoints(false))
+	s.Register(NewTypedHandler("echo", echo))
+	router := http.NewServeMux()
+	router.Handle("/api/rpc/", http.StripPrefix("/api/rpc", s))
+	router.HandleFunc("/api/users", func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusTeapot)
+	})
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/api/rpc/", strings.NewReader(`{"jsonrpc":"2.0","method":"echo","id":1,"params":"hi"}`)))
+	require.Equal(t, `{"jsonrpc":"2.0","result":"hi","id":1}`, recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/users", nil))
+	require.Equal(t, http.StatusTeapot, recorder.Code)
+
+	s = New(WithRPCPath("/v1/rpc"))
+	s.Register(NewTypedHandler("echo", echo))
+	recorder = httptest.NewRecorder()
+	s.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/v1/rpc", strings.NewReader(`{"jsonrpc":"2.0","method":"echo","id":1,"params":"hi"}`)))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	recorder = httptest.NewRecorder()
+	s.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/health", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	require.PanicsWithValue(t, `rpc path "rpc" must start with /`, func() { New(WithRPCPath("rpc")) })
+}
+
+func TestRequestDecoding(t *testing.T) {
+	s := New()
+	s.Register(NewTypedHandler("echo", echo))
+	s.Register(NewTypedHandler("raw", func(ctx context.Context, headers http.Header, id ID, params json.RawMessage) (string, error) {
+		return string(params), nil
+	}))
+
+	recorder := serve(t, s, " \r\n\t"+`[{"jsonrpc":"2.0","method":"echo","id":1,"params":"a"}]`+"\n")
+	require.Equal(t, `[{"jsonrpc":"2.0","result":"a","id":1}]`, recorder.Body.String())
+
+	recorder = serve(t, s, `{"jsonrpc":"2.0","method":"raw","id":1,"params": {"b" : [1, 2]}}`)
+	require.Equal(t, `{"jsonrpc":"2.0","result":"{\"b\" : [1, 2]}","id":1}`, recorder.Body.String())
+
+	recorder = serve(t, s, `{"jsonrpc":"2.0","method":"echo","id":1}`)
+	require.Equal(t, `{"jsonrpc":"2.0","result":"","id":1}`, recorder.Body.String())
+
+	recorder = serve(t, s, `{"jsonrpc":"2.0","method":"echo","id":1,"params":null}`)
+	require.Equal(t, `{"jsonrpc":"2.0","result":"","id":1}`, recorder.Body.String())
+
+	for _, body := range []string{``, `   `, `{"jsonrpc":"2.0","method":"echo","id":1} {}`, `{"jsonrpc":"2.0","method":"echo","id":1`} {
+		recorder = serve(t, s, body)
+		require.Equal(t, http.StatusBadRequest, recorder.Code, body)
+		require.Contains(t, recorder.Body.String(), `"code":-32700`, body)
+	}
+}
+
+func TestMalformedBatchEntries(t *testing.T) {
+	s := New()
+	s.Register(NewTypedHandler("echo", echo))
+
+	recorder := serve(t, s, `[
+		{"jsonrpc":"2.0","method":"echo","id":1,"params":"a"},
+		{"jsonrpc":"2.0","method":5,"id":2},
+		{"jsonrpc":"2.0","method":"echo","id":{"a":1}},
+		1,
+		{"foo":"boo"},
+		{"jsonrpc":"2.0","method":"echo","params":"quiet"}
+	]`)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var responses []struct {
+		Result string `json:"result"`
+		Error  *struct {
+			Code int `json:"code"`
+		} `json:"error"`
+		ID ID `json:"id"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &responses))
+	require.Len(t, responses, 5)
+	invalid := map[string]int{}
+	for _, response := range responses {
+		if response.Error == nil {
+			require.Equal(t, "a", response.Result)
+			require.Equal(t, "1", response.ID.String())
+			continue
+		}
+		require.Equal(t, -32600, response.Error.Code)
+		invalid[response.ID.String()]++
+	}
+	require.Equal(t, map[string]int{"2": 1, "null": 3}, invalid)
+
+	recorder = serve(t, s, `[{"jsonrpc":"2.0","method":"echo","id":1,"params":"a"}, {"jsonrpc":"2.0","method"]`)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+	require.Contains(t, recorder.Body.String(), `"code":-32700`)
+}
+
+// sleeper is a handler sleeping for the number of milliseconds of its params, which tracks the order in which
+// requests are executed and how many of them run concurrently.
+type sleeper struct {
+	lock        sync.Mutex
+	executed    []int
+	inFlight    int
+	maxInFlight int
+}
+
+func (s *sleeper) sleep(ctx context.Context, headers http.Header, id ID, params int) (int, error) {
+	s.lock.Lock()
+	s.inFlight++
+	s.maxInFlight = max(s.maxInFlight, s.inFlight)
+	s.executed = append(s.executed, params)
+	s.lock.Unlock()
+	time.Sleep(time.Duration(params) * time.Millisecond)
+	s.lock.Lock()
+	s.inFlight--
+	s.lock.Unlock()
+	return params, nil
+}
+
+func TestBatchOrdering(t *testing.T) {
+	body := `[
+		{"jsonrpc":"2.0","method":"sleep","id":1,"params":40},
+		{"jsonrpc":"2.0","method":"sleep","params":0},
+		{"jsonrpc":"2.0","method":"sleep","id":2,"params":20},
+		{"jsonrpc":"2.0","method":"missing","id":3},
+		{"jsonrpc":"2.0","method":"sleep","id":4,"params":0}
+	]`
+	expected := `[{"jsonrpc":"2.0","result":40,"id":1},{"jsonrpc":"2.0","result":20,"id":2},` +
+		`{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found","data":{}},"id":3},{"jsonrpc":"2.0","result":0,"id":4}]`
+
+	handler := &sleeper{}
+	s := New(WithOrderedBatchResponses(true))
+	s.Register(NewTypedHandler("sleep", handler.sleep))
+	recorder := serve(t, s, body)
+	require.Equal(t, expected, recorder.Body.String())
+	// The requests are still executed concurrently.
+	require.Greater(t, handler.maxInFlight, 1)
+
+	handler = &sleeper{}
+	s = New(WithSequentialBatchExecution(true))
+	s.Register(NewTypedHandler("sleep", handler.sleep))
+	recorder = serve(t, s, body)
+	require.Equal(t, expected, recorder.Body.String())
+	require.Equal(t, []int{40, 0, 20, 0}, handler.executed)
+	require.Equal(t, 1, handler.maxInFlight)
+}
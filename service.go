@@ -0,0 +1,88 @@
+package jsonrpc
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+)
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// RegisterService registers every exported method of svc with the signature
+//
+//	func(ctx context.Context, args Args) (Reply, error)
+//
+// as the rpc method "prefix.Method". Methods with any other signature are skipped.
+// An empty prefix registers the methods under their own name.
+// Args are validated against their validate struct tags, as done by [NewTypedHandler].
+func (j *jsonRPCServer) RegisterService(prefix string, svc interface{}) {
+	v := reflect.ValueOf(svc)
+	t := v.Type()
+	registered := 0
+	for i := 0; i < t.NumMethod(); i++ {
+		method := t.Method(i)
+		if !method.IsExported() || !isServiceMethod(method.Type) {
+			continue
+		}
+		name := method.Name
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+		j.Register(&serviceMethod{
+			methodName: name,
+			fn:         v.Method(i),
+			argType:    method.Type.In(2),
+			validate:   hasRules(method.Type.In(2)),
+		})
+		registered++
+	}
+	if registered == 0 {
+		panic("service " + t.String() + " has no methods suitable for registration")
+	}
+}
+
+// isServiceMethod reports whether the method type (including its receiver) has the form
+// func(recv, context.Context, Args) (Reply, error).
+func isServiceMethod(t reflect.Type) bool {
+	return t.NumIn() == 3 &&
+		t.In(1) == contextType &&
+		t.NumOut() == 2 &&
+		t.Out(1) == errorType
+}
+
+// serviceMethod adapts a method found by RegisterService to the RPCHandler interface.
+type serviceMethod struct {
+	methodName string
+	fn         reflect.Value
+	argType    reflect.Type
+	// validate is set when the argument type declares validate struct tags.
+	validate bool
+}
+
+func (s *serviceMethod) MethodName() string {
+	return s.methodName
+}
+
+func (s *serviceMethod) Execute(ctx context.Context, headers http.Header, id ID, params interface{}) (interface{}, error) {
+	arg := reflect.New(s.argType)
+	if err := unmarshalParams(params, arg.Interface()); err != nil {
+		return nil, NewInvalidParamsError(id, NewDetail("rationale", err.Error()))
+	}
+	if s.validate {
+		if details := validateParamsStruct(arg.Interface()); len(details) > 0 {
+			return nil, NewInvalidParamsError(id, details...)
+		}
+	}
+	out := s.fn.Call([]reflect.Value{reflect.ValueOf(ctx), arg.Elem()})
+	if err, _ := out[1].Interface().(error); err != nil {
+		return nil, err
+	}
+	return out[0].Interface(), nil
+}
+
+func (s *serviceMethod) ParametersValid(ctx context.Context, params interface{}) ([]Detail, bool) {
+	return nil, true
+}
@@ -0,0 +1,49 @@
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type arithArgs struct {
+	A int `json:"a"`
+	B int `json:"b"`
+}
+
+type arith struct{}
+
+func (arith) Add(ctx context.Context, args arithArgs) (int, error) {
+	return args.A + args.B, nil
+}
+
+func (arith) Divide(ctx context.Context, args arithArgs) (int, error) {
+	if args.B == 0 {
+		return 0, errors.New("division by zero")
+	}
+	return args.A / args.B, nil
+}
+
+func (arith) NotAnRPCMethod(a, b int) int {
+	return a + b
+}
+
+func TestRegisterService(t *testing.T) {
+	s := New().(*jsonRPCServer)
+	s.RegisterService("arith", arith{})
+	require.Len(t, s.methods, 2)
+
+	recorder := httptest.NewRecorder()
+	s.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(`{"jsonrpc":"2.0","method":"arith.Add","id":"1","params":{"a":2,"b":3}}`)))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.JSONEq(t, `{"jsonrpc":"2.0","result":5,"id":"1"}`, recorder.Body.String())
+
+	require.Panics(t, func() {
+		s.RegisterService("arith", arith{})
+	})
+}
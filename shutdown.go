@@ -0,0 +1,65 @@
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"net"
+)
+
+// Shutdown gracefully stops the server. It flips the readiness endpoint to 503, stops accepting connections
+// and new messages on persistent connections, then waits for the single and batch requests in flight to complete.
+// When the server is mounted in another http server, new requests on the rpc endpoint are answered with a 503.
+// Shutdown returns the context error if ctx expires first.
+//
+// Streams served with ServeStream stop reading after Shutdown, but a read already blocked on the underlying
+// reader only returns once that reader does.
+func (j *jsonRPCServer) Shutdown(ctx context.Context) error {
+	j.lock.Lock()
+	if !j.draining.Swap(true) {
+		close(j.shutdown)
+	}
+	httpServers := j.httpServers
+	listeners := j.listeners
+	j.listeners = make(map[net.Listener]struct{})
+	j.lock.Unlock()
+
+	var errs []error
+	for listener := range listeners {
+		if err := listener.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+			errs = append(errs, err)
+		}
+	}
+	for _, server := range httpServers {
+		if err := server.Shutdown(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		j.active.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		select {
+		case <-done:
+		default:
+			return ctx.Err()
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// track runs register while holding the server lock, unless the server is shutting down.
+// It returns false if the server is shutting down.
+func (j *jsonRPCServer) track(register func()) bool {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	if j.draining.Load() {
+		return false
+	}
+	register()
+	return true
+}
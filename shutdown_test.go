@@ -0,0 +1,108 @@
+package jsonrpc
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestShutdown(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	s := New()
+	s.Register(NewTypedHandler("slow", func(ctx context.Context, headers http.Header, id ID, params string) (string, error) {
+		close(started)
+		<-release
+		return params, nil
+	}))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	served := make(chan error, 1)
+	go func() { served <- s.Serve(listener, NewLineCodec) }()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	codec := NewLineCodec(conn, conn)
+	require.NoError(t, codec.WriteMessage([]byte(`{"jsonrpc":"2.0","method":"slow","id":1,"params":"done"}`)))
+	<-started
+
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- s.Shutdown(context.Background()) }()
+	require.NoError(t, <-served)
+
+	recorder := httptest.NewRecorder()
+	s.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readiness", nil))
+	require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+
+	select {
+	case <-shutdown:
+		t.Fatal("shutdown returned before the request in flight completed")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	require.NoError(t, <-shutdown)
+	message, err := codec.ReadMessage()
+	require.NoError(t, err)
+	require.Equal(t, `{"jsonrpc":"2.0","result":"done","id":1}`, string(message))
+
+	require.NoError(t, s.Shutdown(context.Background()))
+	require.ErrorIs(t, s.Serve(listener, NewLineCodec), http.ErrServerClosed)
+}
+
+func TestShutdownMountedHandler(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	s := New()
+	s.Register(NewTypedHandler("slow", func(ctx context.Context, headers http.Header, id ID, params string) (string, error) {
+		close(started)
+		<-release
+		return params, nil
+	}))
+	mux := http.NewServeMux()
+	mux.Handle("/rpc", s)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	responded := make(chan string, 1)
+	go func() {
+		response, err := http.Post(server.URL+"/rpc", "application/json", strings.NewReader(`{"jsonrpc":"2.0","method":"slow","id":1,"params":"done"}`))
+		if err != nil {
+			responded <- err.Error()
+			return
+		}
+		defer response.Body.Close()
+		body, _ := io.ReadAll(response.Body)
+		responded <- string(body)
+	}()
+	<-started
+
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- s.Shutdown(context.Background()) }()
+	require.Eventually(t, func() bool {
+		recorder := httptest.NewRecorder()
+		s.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readiness", nil))
+		return recorder.Code == http.StatusServiceUnavailable
+	}, time.Second, time.Millisecond)
+	response, err := http.Post(server.URL+"/rpc", "application/json", strings.NewReader(`{"jsonrpc":"2.0","method":"slow","id":2,"params":"late"}`))
+	require.NoError(t, err)
+	_ = response.Body.Close()
+	require.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+
+	select {
+	case <-shutdown:
+		t.Fatal("shutdown returned before the request in flight completed")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	require.NoError(t, <-shutdown)
+	require.Equal(t, `{"jsonrpc":"2.0","result":"done","id":1}`, <-responded)
+}
@@ -0,0 +1,254 @@
+package jsonrpc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"sync"
+)
+
+var (
+	errMessageTooLarge = errors.New("rpc message exceeds the maximum request size")
+	errHeaderTooLarge  = errors.New("rpc message header exceeds the maximum header size")
+)
+
+// maxHeaderSize bounds the header block of every message read by the codec of NewHeaderCodec, so that a peer
+// cannot send header lines forever before its Content-Length is checked against the maximum request size.
+const maxHeaderSize = 8 * 1024
+
+// Codec reads and writes whole rpc messages over a persistent connection.
+// ReadMessage is only called from one goroutine at a time, and so is WriteMessage.
+type Codec interface {
+	// ReadMessage returns the next message. It returns io.EOF once the peer is done sending messages.
+	ReadMessage() ([]byte, error)
+	// WriteMessage sends a message to the peer.
+	WriteMessage(message []byte) error
+}
+
+// readLimiter is implemented by the codecs of this package to refuse messages larger than maxRequestSize.
+type readLimiter interface {
+	setReadLimit(limit int64)
+}
+
+// ServeStream serves rpc requests read from codec until it returns io.EOF or ctx is done, and writes back a response
+// to each of them.
+// Up to batchRequestParallelism messages are executed concurrently; ServeStream returns once all of them are done.
+func (j *jsonRPCServer) ServeStream(ctx context.Context, codec Codec) error {
+	return j.serveMessages(ctx, http.Header{}, codec)
+}
+
+// serveMessages reads messages from codec until it fails, ctx is done or the server shuts down, and writes back the
+// response to each of them. Reaching the end of the stream is not reported as an error.
+func (j *jsonRPCServer) serveMessages(ctx context.Context, headers http.Header, codec Codec) error {
+	if limiter, ok := codec.(readLimiter); ok {
+		limiter.setReadLimit(j.opts.maxRequestSize)
+	}
+	if !j.track(func() { j.active.Add(1) }) {
+		return nil
+	}
+	defer j.active.Done()
+
+	// Messages are read from a separate goroutine so that a blocked read does not delay a shutdown.
+	type read struct {
+		message []byte
+		err     error
+	}
+	reads := make(chan read)
+	go func() {
+		for {
+			message, err := codec.ReadMessage()
+			select {
+			case reads <- read{message: message, err: err}:
+			case <-j.shutdown:
+				return
+			case <-ctx.Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	inFlight := make(chan struct{}, j.opts.batchRequestParallelism)
+	wg := sync.WaitGroup{}
+	writeLock := sync.Mutex{}
+	defer wg.Wait()
+	for {
+		var next read
+		select {
+		case next = <-reads:
+		case <-j.shutdown:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if next.err != nil {
+			if errors.Is(next.err, io.EOF) || errors.Is(next.err, errWebSocketClosed) {
+				return nil
+			}
+			return next.err
+		}
+		inFlight <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-inFlight
+				wg.Done()
+			}()
+			_, response := j.handleMessage(ctx, headers, next.message)
+			if response == nil {
+				return
+			}
+			writeLock.Lock()
+			defer writeLock.Unlock()
+			// The responses of a websocket connection closed while they were executing are dropped on purpose.
+			if err := codec.WriteMessage(response); err != nil && !errors.Is(err, errWebSocketClosed) {
+				slog.Error("Failed to write rpc message", "error", err)
+			}
+		}()
+	}
+}
+
+// NewHeaderCodec returns a Codec that frames every message with a Content-Length header,
+// as done by the language server protocol:
+//
+//	Content-Length: 54\r\n
+//	\r\n
+//	{"jsonrpc":"2.0","method":"add","params":[1,2],"id":1}
+//
+// Reading fails once the headers of a message exceed 8KB.
+func NewHeaderCodec(r io.Reader, w io.Writer) Codec {
+	return &headerCodec{
+		reader:    bufio.NewReader(r),
+		writer:    w,
+		readLimit: defaultOpts().maxRequestSize,
+	}
+}
+
+type headerCodec struct {
+	reader    *bufio.Reader
+	writer    io.Writer
+	readLimit int64
+}
+
+func (h *headerCodec) setReadLimit(limit int64) {
+	h.readLimit = limit
+}
+
+func (h *headerCodec) ReadMessage() ([]byte, error) {
+	header, err := h.readHeader()
+	if err != nil {
+		return nil, err
+	}
+	contentLength := header.Get("Content-Length")
+	if contentLength == "" {
+		return nil, errors.New("rpc message is missing the Content-Length header")
+	}
+	length, err := strconv.ParseInt(contentLength, 10, 64)
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("invalid Content-Length header %q", contentLength)
+	}
+	if length > h.readLimit {
+		return nil, errMessageTooLarge
+	}
+	message := make([]byte, length)
+	if _, err := io.ReadFull(h.reader, message); err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+// readHeader reads the header block of the next message, up to the empty line ending it, and fails once the block
+// exceeds maxHeaderSize.
+func (h *headerCodec) readHeader() (textproto.MIMEHeader, error) {
+	var block []byte
+	lineStart := 0
+	for {
+		chunk, err := h.reader.ReadSlice('\n')
+		if len(block)+len(chunk) > maxHeaderSize {
+			return nil, errHeaderTooLarge
+		}
+		block = append(block, chunk...)
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimRight(block[lineStart:], "\r\n")) == 0 {
+			break
+		}
+		lineStart = len(block)
+	}
+	return textproto.NewReader(bufio.NewReader(bytes.NewReader(block))).ReadMIMEHeader()
+}
+
+func (h *headerCodec) WriteMessage(message []byte) error {
+	frame := make([]byte, 0, len(message)+32)
+	frame = append(frame, "Content-Length: "...)
+	frame = strconv.AppendInt(frame, int64(len(message)), 10)
+	frame = append(frame, "\r\n\r\n"...)
+	frame = append(frame, message...)
+	_, err := h.writer.Write(frame)
+	return err
+}
+
+// NewLineCodec returns a Codec for newline-delimited JSON, where every line holds one message.
+// Empty lines are ignored.
+func NewLineCodec(r io.Reader, w io.Writer) Codec {
+	return &lineCodec{
+		reader:    bufio.NewReader(r),
+		writer:    w,
+		readLimit: defaultOpts().maxRequestSize,
+	}
+}
+
+type lineCodec struct {
+	reader    *bufio.Reader
+	writer    io.Writer
+	readLimit int64
+}
+
+func (l *lineCodec) setReadLimit(limit int64) {
+	l.readLimit = limit
+}
+
+func (l *lineCodec) ReadMessage() ([]byte, error) {
+	for {
+		var line []byte
+		for {
+			chunk, err := l.reader.ReadSlice('\n')
+			if int64(len(line)+len(chunk)) > l.readLimit+1 {
+				return nil, errMessageTooLarge
+			}
+			line = append(line, chunk...)
+			if errors.Is(err, bufio.ErrBufferFull) {
+				continue
+			}
+			if err != nil && (!errors.Is(err, io.EOF) || len(line) == 0) {
+				return nil, err
+			}
+			break
+		}
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 {
+			return line, nil
+		}
+	}
+}
+
+func (l *lineCodec) WriteMessage(message []byte) error {
+	frame := make([]byte, 0, len(message)+1)
+	frame = append(frame, message...)
+	frame = append(frame, '\n')
+	_, err := l.writer.Write(frame)
+	return err
+}
@@ -0,0 +1,63 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestServeStreamHeaderCodec(t *testing.T) {
+	s := New()
+	s.Register(NewTypedHandler("echo", echo))
+	message := `{"jsonrpc":"2.0","method":"echo","id":1,"params":"hi"}`
+	input := "Content-Length: 54\r\nContent-Type: application/vscode-jsonrpc; charset=utf-8\r\n\r\n" + message +
+		"Content-Length: 50\r\n\r\n" + `{"jsonrpc":"2.0","method":"echo","params":"quiet"}`
+	output := bytes.Buffer{}
+	require.NoError(t, s.ServeStream(context.Background(), NewHeaderCodec(strings.NewReader(input), &output)))
+	require.Equal(t, "Content-Length: 38\r\n\r\n"+`{"jsonrpc":"2.0","result":"hi","id":1}`, output.String())
+
+	input = strings.Repeat("X-Padding: "+strings.Repeat("x", 100)+"\r\n", 100) + "Content-Length: 54\r\n\r\n" + message
+	require.ErrorIs(t, s.ServeStream(context.Background(), NewHeaderCodec(strings.NewReader(input), io.Discard)), errHeaderTooLarge)
+	input = "Content-Length: 54\r\n" + strings.Repeat("x", maxHeaderSize)
+	require.ErrorIs(t, s.ServeStream(context.Background(), NewHeaderCodec(strings.NewReader(input), io.Discard)), errHeaderTooLarge)
+}
+
+func TestServeStreamLineCodec(t *testing.T) {
+	s := New(WithMaxRequestSize(64))
+	s.Register(NewTypedHandler("echo", echo))
+	input := `{"jsonrpc":"2.0","method":"echo","id":1,"params":"hi"}` + "\n\n" + "not json\n"
+	output := bytes.Buffer{}
+	require.NoError(t, s.ServeStream(context.Background(), NewLineCodec(strings.NewReader(input), &output)))
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	require.Len(t, lines, 2)
+	require.Contains(t, output.String(), `{"jsonrpc":"2.0","result":"hi","id":1}`+"\n")
+	require.Contains(t, output.String(), `"code":-32700`)
+
+	input = `{"jsonrpc":"2.0","method":"echo","id":1,"params":"` + strings.Repeat("x", 64) + `"}` + "\n"
+	require.ErrorIs(t, s.ServeStream(context.Background(), NewLineCodec(strings.NewReader(input), &output)), errMessageTooLarge)
+}
+
+func TestServeStreamContextCancelled(t *testing.T) {
+	s := New()
+	s.Register(NewTypedHandler("echo", echo))
+	// The pipe is never written to, so reading blocks until the context is cancelled.
+	reader, writer := io.Pipe()
+	defer writer.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- s.ServeStream(ctx, NewLineCodec(reader, io.Discard))
+	}()
+	cancel()
+	select {
+	case err := <-done:
+		require.ErrorIs(t, err, context.Canceled)
+	case <-time.After(5 * time.Second):
+		t.Fatal("ServeStream did not return once its context was cancelled")
+	}
+}
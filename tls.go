@@ -0,0 +1,68 @@
+package jsonrpc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+)
+
+const (
+	// PeerCertificateParamKey is the key of the context param holding the verified *x509.Certificate of the client
+	// when mutual TLS is enabled with [WithClientCAs].
+	PeerCertificateParamKey = "tls.peer.certificate"
+	// PeerSubjectParamKey is the key of the context param holding the subject of the verified client certificate.
+	PeerSubjectParamKey = "tls.peer.subject"
+)
+
+// tlsConfig returns the TLS configuration built from the TLS options, or nil if TLS is not enabled.
+// Connections are refused below TLS 1.2 unless the config of WithTLSConfig sets its own MinVersion.
+func (o *serverOpts) tlsConfig() (*tls.Config, error) {
+	if o.tls == nil && o.certFile == "" && o.clientCAs == nil {
+		return nil, nil
+	}
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if o.tls != nil {
+		config = o.tls.Clone()
+		if config.MinVersion == 0 {
+			config.MinVersion = tls.VersionTLS12
+		}
+	}
+	if o.certFile != "" {
+		certificate, err := tls.LoadX509KeyPair(o.certFile, o.keyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = append(config.Certificates, certificate)
+	}
+	if o.clientCAs != nil {
+		config.ClientCAs = o.clientCAs
+		// A stricter or looser policy set on the config of WithTLSConfig is kept.
+		if config.ClientAuth == tls.NoClientCert {
+			config.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+	return config, nil
+}
+
+// peerParams returns the context params describing the verified client certificate of a TLS connection.
+func peerParams(state *tls.ConnectionState) []Param {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	certificate := state.VerifiedChains[0][0]
+	return []Param{
+		SafeParam(PeerCertificateParamKey, certificate),
+		SafeParam(PeerSubjectParamKey, certificate.Subject.String()),
+	}
+}
+
+// PeerCertificateFromContext returns the verified certificate of the client that sent the request.
+// It is only available when mutual TLS is enabled with [WithClientCAs].
+func PeerCertificateFromContext(ctx context.Context) (*x509.Certificate, bool) {
+	param, ok := ParamFromContext(ctx, PeerCertificateParamKey)
+	if !ok {
+		return nil, false
+	}
+	certificate, ok := param.Value().(*x509.Certificate)
+	return certificate, ok
+}
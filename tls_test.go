@@ -0,0 +1,98 @@
+package jsonrpc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// issueCertificate returns a certificate for commonName signed by parent, or self-signed when parent is nil.
+func issueCertificate(t *testing.T, commonName string, parent *tls.Certificate) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+	signer, signerKey := template, interface{}(key)
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		signer, signerKey = parent.Leaf, parent.PrivateKey
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+func TestMutualTLS(t *testing.T) {
+	ca := issueCertificate(t, "test ca", nil)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Leaf)
+	serverCertificate := issueCertificate(t, "server", &ca)
+	clientCertificate := issueCertificate(t, "client-42", &ca)
+
+	s := New(WithTLSConfig(&tls.Config{Certificates: []tls.Certificate{serverCertificate}}), WithClientCAs(pool))
+	s.Register(NewTypedHandler("whoami", func(ctx context.Context, headers http.Header, id ID, params interface{}) (string, error) {
+		certificate, ok := PeerCertificateFromContext(ctx)
+		require.True(t, ok)
+		return certificate.Subject.CommonName, nil
+	}))
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = s.Shutdown(context.Background()) }()
+	go func() { _ = s.Serve(listener, NewLineCodec) }()
+
+	conn, err := tls.Dial("tcp", listener.Addr().String(), &tls.Config{RootCAs: pool, Certificates: []tls.Certificate{clientCertificate}})
+	require.NoError(t, err)
+	defer conn.Close()
+	codec := NewLineCodec(conn, conn)
+	require.NoError(t, codec.WriteMessage([]byte(`{"jsonrpc":"2.0","method":"whoami","id":1}`)))
+	message, err := codec.ReadMessage()
+	require.NoError(t, err)
+	require.Equal(t, `{"jsonrpc":"2.0","result":"client-42","id":1}`, string(message))
+
+	conn, err = tls.Dial("tcp", listener.Addr().String(), &tls.Config{RootCAs: pool})
+	require.NoError(t, err)
+	defer conn.Close()
+	codec = NewLineCodec(conn, conn)
+	_ = codec.WriteMessage([]byte(`{"jsonrpc":"2.0","method":"whoami","id":1}`))
+	_, err = codec.ReadMessage()
+	require.Error(t, err)
+}
+
+func TestTLSConfig(t *testing.T) {
+	pool := x509.NewCertPool()
+	config, err := (&serverOpts{tls: &tls.Config{}, clientCAs: pool}).tlsConfig()
+	require.NoError(t, err)
+	require.Equal(t, uint16(tls.VersionTLS12), config.MinVersion)
+	require.Equal(t, tls.RequireAndVerifyClientCert, config.ClientAuth)
+
+	given := &tls.Config{MinVersion: tls.VersionTLS13, ClientAuth: tls.VerifyClientCertIfGiven}
+	config, err = (&serverOpts{tls: given, clientCAs: pool}).tlsConfig()
+	require.NoError(t, err)
+	require.Equal(t, uint16(tls.VersionTLS13), config.MinVersion)
+	require.Equal(t, tls.VerifyClientCertIfGiven, config.ClientAuth)
+	require.Same(t, pool, config.ClientCAs)
+	require.Nil(t, given.ClientCAs)
+}
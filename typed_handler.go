@@ -0,0 +1,118 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+)
+
+// TypedFunc computes the result of a rpc call from parameters that have already been decoded into P.
+type TypedFunc[P any, R any] func(ctx context.Context, headers http.Header, id ID, params P) (R, error)
+
+// TypedHandler adapts a TypedFunc to the RPCHandler interface.
+// Use [NewTypedHandler] to create one.
+type TypedHandler[P any, R any] struct {
+	methodName string
+	fn         TypedFunc[P, R]
+	// validate is set when P declares validate struct tags.
+	validate bool
+	// params are the names declared with WithParamNames, in positional order.
+	params []namedParam
+}
+
+// TypedHandlerOption configures a TypedHandler.
+type TypedHandlerOption = func(opts *typedHandlerOpts)
+
+type typedHandlerOpts struct {
+	paramNames []string
+}
+
+// WithParamNames declares the names of the parameters of a typed handler, in positional order, so that its
+// parameters can be sent either as an object or as an array. Names ending with "?" are optional and must come last.
+// The names are the json names of the fields of P:
+//
+//	NewTypedHandler("subtract", subtract, WithParamNames("minuend", "subtrahend", "precision?"))
+//
+// accepts both [42, 23] and {"minuend": 42, "subtrahend": 23}. Params that are not declared, or required params
+// that are missing, are refused with an invalid params error.
+func WithParamNames(names ...string) TypedHandlerOption {
+	return func(opts *typedHandlerOpts) {
+		opts.paramNames = append(opts.paramNames, names...)
+	}
+}
+
+// NewTypedHandler returns a RPCHandler for methodName that decodes the request parameters into P
+// before calling fn. Parameters that cannot be decoded into P, or that break the rules of its validate struct tags,
+// are reported as an invalid params error. It panics if a validate tag or a param name is invalid.
+func NewTypedHandler[P any, R any](methodName string, fn TypedFunc[P, R], options ...TypedHandlerOption) *TypedHandler[P, R] {
+	opts := &typedHandlerOpts{}
+	for _, option := range options {
+		option(opts)
+	}
+	return &TypedHandler[P, R]{
+		methodName: methodName,
+		fn:         fn,
+		validate:   hasRules(reflect.TypeFor[P]()),
+		params:     parseParamNames(reflect.TypeFor[P](), opts.paramNames),
+	}
+}
+
+func (t *TypedHandler[P, R]) MethodName() string {
+	return t.methodName
+}
+
+// Execute decodes and validates params into P and calls the wrapped TypedFunc.
+// The parameters are only decoded once, here, so ParametersValid always accepts them.
+func (t *TypedHandler[P, R]) Execute(ctx context.Context, headers http.Header, id ID, params interface{}) (interface{}, error) {
+	if t.params != nil {
+		named, details := nameParams(t.params, params)
+		if details != nil {
+			return nil, NewInvalidParamsError(id, details...)
+		}
+		params = named
+	}
+	p, err := decodeParams[P](params)
+	if err != nil {
+		return nil, NewInvalidParamsError(id, NewDetail("rationale", err.Error()))
+	}
+	if t.validate {
+		if details := validateParamsStruct(p); len(details) > 0 {
+			return nil, NewInvalidParamsError(id, details...)
+		}
+	}
+	return t.fn(ctx, headers, id, p)
+}
+
+func (t *TypedHandler[P, R]) ParametersValid(ctx context.Context, params interface{}) ([]Detail, bool) {
+	return nil, true
+}
+
+// decodeParams converts the raw parameters of a request into P.
+// Missing parameters decode to the zero value of P.
+func decodeParams[P any](params interface{}) (P, error) {
+	var p P
+	if v, ok := params.(P); ok {
+		return v, nil
+	}
+	err := unmarshalParams(params, &p)
+	return p, err
+}
+
+// unmarshalParams decodes the raw parameters of a request into the value pointed to by target.
+func unmarshalParams(params interface{}, target interface{}) error {
+	switch v := params.(type) {
+	case nil:
+		return nil
+	case json.RawMessage:
+		if len(v) == 0 {
+			return nil
+		}
+		return json.Unmarshal(v, target)
+	}
+	b, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, target)
+}
@@ -0,0 +1,45 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type sumParams struct {
+	Values []int `json:"values"`
+}
+
+func sum(ctx context.Context, headers http.Header, id ID, params sumParams) (int, error) {
+	total := 0
+	for _, v := range params.Values {
+		total += v
+	}
+	return total, nil
+}
+
+func TestTypedHandler(t *testing.T) {
+	s := New().(*jsonRPCServer)
+	s.Register(NewTypedHandler("sum", sum))
+
+	recorder := httptest.NewRecorder()
+	s.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(`{"jsonrpc":"2.0","method":"sum","id":"1","params":{"values":[1,2,3]}}`)))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.JSONEq(t, `{"jsonrpc":"2.0","result":6,"id":"1"}`, recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	s.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(`{"jsonrpc":"2.0","method":"sum","id":"2","params":{"values":"nope"}}`)))
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+	var resp struct {
+		Error RPCError `json:"error"`
+		ID    ID       `json:"id"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+	require.Equal(t, -32602, resp.Error.Code)
+	require.Equal(t, StringID("2"), resp.ID)
+}
@@ -0,0 +1,322 @@
+package jsonrpc
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// validateTag is the struct tag declaring the rules checked on the parameters of typed handlers and services
+// before they are executed. Rules are separated by commas:
+//   - required: the value is not the zero value of its type, so 0, "" and empty slices are refused.
+//   - min=n, max=n and len=n: bound the value of numbers and the length of strings, slices and maps.
+//     The length of strings is counted in characters.
+//   - oneof=a b c: the value is one of the space separated values.
+//   - pattern=expr: strings match the regular expression. It must be the last rule as the expression may hold commas.
+//
+// Nested structs, and the structs held by pointers, slices, arrays and maps, are validated as well. Rules apply to the
+// value held by pointers, and only required applies to nil pointers.
+//
+//	type CreateUser struct {
+//		Name string   `json:"name" validate:"required,max=64,pattern=^[a-z]+$"`
+//		Role string   `json:"role" validate:"oneof=admin user"`
+//		Tags []string `json:"tags" validate:"max=3"`
+//		Age  int      `json:"age" validate:"min=0,max=150"`
+//	}
+const validateTag = "validate"
+
+// fieldRules are the rules of a struct field.
+type fieldRules struct {
+	index    []int
+	name     string
+	typ      reflect.Type
+	required bool
+	min      *float64
+	max      *float64
+	length   *int
+	oneOf    []string
+	pattern  *regexp.Regexp
+}
+
+// structRules are the rules of the fields of a struct type, including the fields of embedded structs.
+type structRules struct {
+	fields []fieldRules
+}
+
+var (
+	structRulesLock  sync.Mutex
+	structRulesCache = map[reflect.Type]*structRules{}
+)
+
+// rulesOf returns the rules of the fields of the struct type t. It panics if a validate tag is invalid.
+func rulesOf(t reflect.Type) *structRules {
+	structRulesLock.Lock()
+	defer structRulesLock.Unlock()
+	if rules, ok := structRulesCache[t]; ok {
+		return rules
+	}
+	compiled := map[reflect.Type]*structRules{}
+	rules := compileStructRules(t, compiled)
+	for compiledType, r := range compiled {
+		structRulesCache[compiledType] = r
+	}
+	return rules
+}
+
+// compileStructRules compiles the rules of t, and of the struct types of its fields, into compiled.
+func compileStructRules(t reflect.Type, compiled map[reflect.Type]*structRules) *structRules {
+	if rules, ok := structRulesCache[t]; ok {
+		return rules
+	}
+	if rules, ok := compiled[t]; ok {
+		return rules
+	}
+	rules := &structRules{}
+	// The rules are registered before the fields are compiled so that recursive types terminate.
+	compiled[t] = rules
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		r, err := parseFieldRules(field.Tag.Get(validateTag))
+		if err != nil {
+			panic(fmt.Sprintf("invalid validate tag on %s.%s: %s", t, field.Name, err))
+		}
+		r.index = field.Index
+		r.name = name
+		r.typ = field.Type
+		rules.fields = append(rules.fields, r)
+		if elem := structElem(field.Type); elem != nil {
+			compileStructRules(elem, compiled)
+		}
+	}
+	return rules
+}
+
+// hasRules reports whether t, or a struct type reachable from its fields, declares validation rules.
+func hasRules(t reflect.Type) bool {
+	seen := map[reflect.Type]bool{}
+	var visit func(t reflect.Type) bool
+	visit = func(t reflect.Type) bool {
+		if t == nil || seen[t] {
+			return false
+		}
+		seen[t] = true
+		for _, field := range rulesOf(t).fields {
+			if field.declared() || visit(structElem(field.typ)) {
+				return true
+			}
+		}
+		return false
+	}
+	return visit(structElem(t))
+}
+
+// declared reports whether the field has at least one rule.
+func (r fieldRules) declared() bool {
+	return r.required || r.min != nil || r.max != nil || r.length != nil || r.oneOf != nil || r.pattern != nil
+}
+
+// structElem returns the struct type held by t, through pointers, slices, arrays and maps, or nil if there is none.
+func structElem(t reflect.Type) reflect.Type {
+	for {
+		switch t.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+			t = t.Elem()
+		case reflect.Struct:
+			return t
+		default:
+			return nil
+		}
+	}
+}
+
+func parseFieldRules(tag string) (fieldRules, error) {
+	r := fieldRules{}
+	for tag != "" {
+		var rule string
+		if strings.HasPrefix(tag, "pattern=") {
+			rule, tag = tag, ""
+		} else {
+			rule, tag, _ = strings.Cut(tag, ",")
+		}
+		name, arg, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		switch name {
+		case "":
+		case "required":
+			r.required = true
+		case "min", "max":
+			bound, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				return r, fmt.Errorf("%s must be a number", name)
+			}
+			if name == "min" {
+				r.min = &bound
+			} else {
+				r.max = &bound
+			}
+		case "len":
+			length, err := strconv.Atoi(arg)
+			if err != nil || length < 0 {
+				return r, fmt.Errorf("len must be a non negative integer")
+			}
+			r.length = &length
+		case "oneof":
+			r.oneOf = strings.Fields(arg)
+			if len(r.oneOf) == 0 {
+				return r, fmt.Errorf("oneof must list at least one value")
+			}
+		case "pattern":
+			pattern, err := regexp.Compile(arg)
+			if err != nil {
+				return r, err
+			}
+			r.pattern = pattern
+		default:
+			return r, fmt.Errorf("unknown rule %q", name)
+		}
+	}
+	return r, nil
+}
+
+// validateParamsStruct returns a Detail for every field of params that breaks its rules, keyed by the path of the
+// field, e.g. "address.city" or "items[2].name", and holding the list of the broken rules.
+func validateParamsStruct(params interface{}) []Detail {
+	v := reflect.ValueOf(params)
+	if !v.IsValid() || structElem(v.Type()) == nil {
+		return nil
+	}
+	violations := map[string][]string{}
+	var paths []string
+	violate := func(path string, format string, args ...interface{}) {
+		if _, ok := violations[path]; !ok {
+			paths = append(paths, path)
+		}
+		violations[path] = append(violations[path], fmt.Sprintf(format, args...))
+	}
+	validateValue(v, "", violate)
+	details := make([]Detail, 0, len(paths))
+	for _, path := range paths {
+		details = append(details, NewDetail(path, violations[path]))
+	}
+	return details
+}
+
+type violateFunc = func(path string, format string, args ...interface{})
+
+// validateValue validates the structs held by v, which is found at path.
+func validateValue(v reflect.Value, path string, violate violateFunc) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			validateValue(v.Elem(), path, violate)
+		}
+	case reflect.Slice, reflect.Array:
+		if structElem(v.Type().Elem()) == nil {
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			validateValue(v.Index(i), path+"["+strconv.Itoa(i)+"]", violate)
+		}
+	case reflect.Map:
+		if structElem(v.Type().Elem()) == nil {
+			return
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			validateValue(iter.Value(), path+"["+fmt.Sprint(iter.Key().Interface())+"]", violate)
+		}
+	case reflect.Struct:
+		for _, field := range rulesOf(v.Type()).fields {
+			fieldValue, err := v.FieldByIndexErr(field.index)
+			if err != nil {
+				// The field belongs to a nil embedded pointer.
+				continue
+			}
+			fieldPath := field.name
+			if path != "" {
+				fieldPath = path + "." + field.name
+			}
+			field.check(fieldValue, fieldPath, violate)
+			validateValue(fieldValue, fieldPath, violate)
+		}
+	}
+}
+
+// check validates the value of the field against its rules.
+func (r fieldRules) check(v reflect.Value, path string, violate violateFunc) {
+	if r.required && v.IsZero() {
+		violate(path, "is required")
+		return
+	}
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	var number float64
+	isNumber := true
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		number = float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		number = float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		number = v.Float()
+	default:
+		isNumber = false
+	}
+	if isNumber {
+		if r.min != nil && number < *r.min {
+			violate(path, "must be at least %v", *r.min)
+		}
+		if r.max != nil && number > *r.max {
+			violate(path, "must be at most %v", *r.max)
+		}
+		if r.length != nil && number != float64(*r.length) {
+			violate(path, "must be %d", *r.length)
+		}
+	}
+
+	length := -1
+	unit := "items"
+	switch v.Kind() {
+	case reflect.String:
+		length = utf8.RuneCountInString(v.String())
+		unit = "characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		length = v.Len()
+	}
+	if length >= 0 {
+		if r.min != nil && float64(length) < *r.min {
+			violate(path, "must have at least %v %s", *r.min, unit)
+		}
+		if r.max != nil && float64(length) > *r.max {
+			violate(path, "must have at most %v %s", *r.max, unit)
+		}
+		if r.length != nil && length != *r.length {
+			violate(path, "must have exactly %d %s", *r.length, unit)
+		}
+	}
+
+	if r.oneOf != nil && (isNumber || v.Kind() == reflect.String) && !slices.Contains(r.oneOf, fmt.Sprint(v.Interface())) {
+		violate(path, "must be one of %s", strings.Join(r.oneOf, ", "))
+	}
+	if r.pattern != nil && v.Kind() == reflect.String && !r.pattern.MatchString(v.String()) {
+		violate(path, "must match %s", r.pattern.String())
+	}
+}
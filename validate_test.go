@@ -0,0 +1,90 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type address struct {
+	City string `json:"city" validate:"required"`
+	Zip  string `json:"zip" validate:"len=5,pattern=^[0-9]+$"`
+}
+
+type createUser struct {
+	Name      string             `json:"name" validate:"required,max=8,pattern=^[a-z]+$"`
+	Role      string             `json:"role" validate:"oneof=admin user"`
+	Age       int                `json:"age" validate:"min=18,max=150"`
+	Nickname  *string            `json:"nickname" validate:"min=2"`
+	Tags      []string           `json:"tags" validate:"max=2"`
+	Address   address            `json:"address"`
+	Previous  []*address         `json:"previous"`
+	Contacts  map[string]address `json:"contacts"`
+	Manager   *createUser        `json:"manager"`
+	Untouched string             `json:"-" validate:"required"`
+}
+
+func TestStructTagValidation(t *testing.T) {
+	executed := false
+	s := New()
+	s.Register(NewTypedHandler("user.create", func(ctx context.Context, headers http.Header, id ID, params createUser) (string, error) {
+		executed = true
+		return params.Name, nil
+	}))
+
+	recorder := serve(t, s, `{"jsonrpc":"2.0","method":"user.create","id":1,"params":{"name":"ada","role":"admin","age":36,"address":{"city":"London","zip":"12345"}}}`)
+	require.Equal(t, `{"jsonrpc":"2.0","result":"ada","id":1}`, recorder.Body.String())
+	require.True(t, executed)
+
+	executed = false
+	recorder = serve(t, s, `{"jsonrpc":"2.0","method":"user.create","id":2,"params":{
+		"name":"Ada Lovelace","role":"root","age":12,"nickname":"a","tags":["a","b","c"],
+		"address":{"zip":"1234a"},
+		"previous":[{"city":"Paris","zip":"75001"},null,{"zip":"00000"}],
+		"contacts":{"home":{"zip":"11111"}},
+		"manager":{"name":"bob","role":"user","age":40,"address":{"city":"Rome","zip":"00100"},"manager":{"age":200}}
+	}}`)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+	require.False(t, executed)
+	var response struct {
+		Error RPCError `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	require.Equal(t, -32602, response.Error.Code)
+	b, err := json.Marshal(response.Error.Data)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"name": ["must have at most 8 characters", "must match ^[a-z]+$"],
+		"role": ["must be one of admin, user"],
+		"age": ["must be at least 18"],
+		"nickname": ["must have at least 2 characters"],
+		"tags": ["must have at most 2 items"],
+		"address.city": ["is required"],
+		"address.zip": ["must match ^[0-9]+$"],
+		"previous[2].city": ["is required"],
+		"contacts[home].city": ["is required"],
+		"manager.manager.name": ["is required"],
+		"manager.manager.role": ["must be one of admin, user"],
+		"manager.manager.age": ["must be at most 150"],
+		"manager.manager.address.city": ["is required"],
+		"manager.manager.address.zip": ["must have exactly 5 characters", "must match ^[0-9]+$"]
+	}`, string(b))
+}
+
+type invalidTag struct {
+	Name string `validate:"min=a"`
+}
+
+func TestStructTagValidationMisuse(t *testing.T) {
+	require.PanicsWithValue(t, "invalid validate tag on jsonrpc.invalidTag.Name: min must be a number", func() {
+		NewTypedHandler("invalid", func(ctx context.Context, headers http.Header, id ID, params invalidTag) (string, error) {
+			return "", nil
+		})
+	})
+	require.False(t, hasRules(reflect.TypeFor[sumParams]()))
+	require.True(t, hasRules(reflect.TypeFor[[]createUser]()))
+}
@@ -0,0 +1,289 @@
+package jsonrpc
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// websocketGUID is the magic value used to compute the Sec-WebSocket-Accept header (RFC 6455 section 1.3).
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// wsWriteTimeout bounds how long writing a single frame to a slow peer can block.
+const wsWriteTimeout = 10 * time.Second
+
+const (
+	wsOpContinuation = 0x0
+	wsOpText         = 0x1
+	wsOpBinary       = 0x2
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xA
+)
+
+const (
+	wsCloseNormal          = 1000
+	wsCloseGoingAway       = 1001
+	wsCloseProtocolError   = 1002
+	wsCloseMessageTooLarge = 1009
+)
+
+var (
+	errWebSocketClosed   = errors.New("websocket closed by peer")
+	errWebSocketProtocol = errors.New("websocket protocol error")
+	errWebSocketTooLarge = errors.New("websocket message exceeds the maximum request size")
+)
+
+// isWebSocketUpgrade returns true if the request asks to switch the connection to the websocket protocol.
+func isWebSocketUpgrade(request *http.Request) bool {
+	return request.Method == http.MethodGet &&
+		headerContainsToken(request.Header, "Connection", "upgrade") &&
+		headerContainsToken(request.Header, "Upgrade", "websocket")
+}
+
+func headerContainsToken(header http.Header, name string, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, t := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// allowedOrigin returns true if a websocket upgrade can be accepted from the origin of the request. Browsers send the
+// Origin header with every upgrade and the server relies on it to refuse pages of other sites, which would otherwise
+// use the cookies of their visitors; clients that are not browsers usually do not send it and are accepted.
+func (j *jsonRPCServer) allowedOrigin(request *http.Request) bool {
+	origin := request.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if slices.ContainsFunc(j.opts.websocketOrigins, func(allowed string) bool {
+		return allowed == "*" || strings.EqualFold(allowed, origin)
+	}) {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host != "" && strings.EqualFold(u.Host, request.Host)
+}
+
+// serveWebSocket upgrades the connection and serves rpc requests from every websocket message until the
+// connection is closed. Each message can hold a single or batch request, and up to batchRequestParallelism
+// messages are executed concurrently. Upgrades from another origin than the host of the server are refused
+// unless allowed with WithWebSocketOrigins.
+func (j *jsonRPCServer) serveWebSocket(ctx context.Context, writer http.ResponseWriter, request *http.Request) {
+	if !j.allowedOrigin(request) {
+		writer.WriteHeader(http.StatusForbidden)
+		return
+	}
+	key := request.Header.Get("Sec-WebSocket-Key")
+	if request.Header.Get("Sec-WebSocket-Version") != "13" || key == "" {
+		writer.Header().Set("Sec-WebSocket-Version", "13")
+		writer.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	hijacker, ok := writer.(http.Hijacker)
+	if !ok {
+		writer.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		slog.Error("Failed to hijack websocket connection", "error", err)
+		return
+	}
+	accept := sha1.Sum([]byte(key + websocketGUID))
+	handshake := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(accept[:]) + "\r\n\r\n"
+	if _, err := rw.WriteString(handshake); err != nil {
+		slog.Error("Failed to write websocket handshake", "error", err)
+		_ = conn.Close()
+		return
+	}
+	if err := rw.Flush(); err != nil {
+		slog.Error("Failed to write websocket handshake", "error", err)
+		_ = conn.Close()
+		return
+	}
+	ws := &wsConn{conn: conn, reader: rw.Reader}
+	if err := j.serveMessages(ctx, request.Header, ws); err != nil {
+		slog.Error("Failed to read websocket message", "error", err)
+	}
+	if j.draining.Load() {
+		_ = ws.writeClose(wsCloseGoingAway)
+	}
+	_ = ws.Close()
+}
+
+// wsConn is the server side of a websocket connection.
+type wsConn struct {
+	conn      net.Conn
+	reader    *bufio.Reader
+	readLimit int64
+	writeLock sync.Mutex
+	// closeSent is set once a close frame has been sent, after which no other frame may be sent (RFC 6455 section
+	// 5.5.1). Responses of the messages still executing at that point are dropped.
+	closeSent bool
+}
+
+func (w *wsConn) setReadLimit(limit int64) {
+	w.readLimit = limit
+}
+
+// ReadMessage returns the payload of the next text or binary message, answering control frames on the way.
+func (w *wsConn) ReadMessage() ([]byte, error) {
+	var message []byte
+	started := false
+	for {
+		fin, opcode, payload, err := w.readFrame(int64(len(message)))
+		if err != nil {
+			switch {
+			case errors.Is(err, errWebSocketTooLarge):
+				_ = w.writeClose(wsCloseMessageTooLarge)
+			case errors.Is(err, errWebSocketProtocol):
+				_ = w.writeClose(wsCloseProtocolError)
+			}
+			return nil, err
+		}
+		switch opcode {
+		case wsOpPing:
+			if err := w.writeFrame(wsOpPong, payload); err != nil {
+				return nil, err
+			}
+			continue
+		case wsOpPong:
+			continue
+		case wsOpClose:
+			_ = w.writeClose(wsCloseNormal)
+			return nil, errWebSocketClosed
+		case wsOpText, wsOpBinary:
+			if started {
+				_ = w.writeClose(wsCloseProtocolError)
+				return nil, errWebSocketProtocol
+			}
+			started = true
+		case wsOpContinuation:
+			if !started {
+				_ = w.writeClose(wsCloseProtocolError)
+				return nil, errWebSocketProtocol
+			}
+		default:
+			_ = w.writeClose(wsCloseProtocolError)
+			return nil, errWebSocketProtocol
+		}
+		message = append(message, payload...)
+		if fin {
+			return message, nil
+		}
+	}
+}
+
+// readFrame reads a single frame. buffered is the size of the message read so far and is used to enforce readLimit.
+func (w *wsConn) readFrame(buffered int64) (fin bool, opcode byte, payload []byte, err error) {
+	var header [2]byte
+	if _, err := io.ReadFull(w.reader, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin = header[0]&0x80 != 0
+	opcode = header[0] & 0x0F
+	if header[0]&0x70 != 0 {
+		return false, 0, nil, errWebSocketProtocol
+	}
+	// Frames sent by a client must always be masked.
+	if header[1]&0x80 == 0 {
+		return false, 0, nil, errWebSocketProtocol
+	}
+	length := uint64(header[1] & 0x7F)
+	switch length {
+	case 126:
+		var extended [2]byte
+		if _, err := io.ReadFull(w.reader, extended[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(extended[:]))
+	case 127:
+		var extended [8]byte
+		if _, err := io.ReadFull(w.reader, extended[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(extended[:])
+	}
+	if opcode >= wsOpClose && (!fin || length > 125) {
+		return false, 0, nil, errWebSocketProtocol
+	}
+	if opcode < wsOpClose && (length > uint64(w.readLimit) || buffered+int64(length) > w.readLimit) {
+		return false, 0, nil, errWebSocketTooLarge
+	}
+	var mask [4]byte
+	if _, err := io.ReadFull(w.reader, mask[:]); err != nil {
+		return false, 0, nil, err
+	}
+	payload = make([]byte, length)
+	if _, err := io.ReadFull(w.reader, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return fin, opcode, payload, nil
+}
+
+// WriteMessage sends message as a single text frame.
+func (w *wsConn) WriteMessage(message []byte) error {
+	return w.writeFrame(wsOpText, message)
+}
+
+func (w *wsConn) writeClose(code uint16) error {
+	payload := make([]byte, 2)
+	binary.BigEndian.PutUint16(payload, code)
+	return w.writeFrame(wsOpClose, payload)
+}
+
+func (w *wsConn) writeFrame(opcode byte, payload []byte) error {
+	w.writeLock.Lock()
+	defer w.writeLock.Unlock()
+	if w.closeSent {
+		return errWebSocketClosed
+	}
+	if opcode == wsOpClose {
+		w.closeSent = true
+	}
+	header := make([]byte, 2, 10)
+	header[0] = 0x80 | opcode
+	switch length := len(payload); {
+	case length <= 125:
+		header[1] = byte(length)
+	case length <= 0xFFFF:
+		header[1] = 126
+		header = binary.BigEndian.AppendUint16(header, uint16(length))
+	default:
+		header[1] = 127
+		header = binary.BigEndian.AppendUint64(header, uint64(length))
+	}
+	_ = w.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	if _, err := w.conn.Write(header); err != nil {
+		return err
+	}
+	_, err := w.conn.Write(payload)
+	return err
+}
+
+func (w *wsConn) Close() error {
+	return w.conn.Close()
+}
@@ -0,0 +1,142 @@
+package jsonrpc
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// dialWebSocket opens a websocket connection to the rpc endpoint of server.
+func dialWebSocket(t *testing.T, server *httptest.Server) (net.Conn, *bufio.Reader) {
+	t.Helper()
+	conn, reader, response := upgradeWebSocket(t, server, "")
+	require.Equal(t, http.StatusSwitchingProtocols, response.StatusCode)
+	require.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", response.Header.Get("Sec-WebSocket-Accept"))
+	return conn, reader
+}
+
+// upgradeWebSocket sends a websocket upgrade for the host localhost to the rpc endpoint of server, with the
+// Origin header set to origin unless it is empty, and returns the response of the server.
+func upgradeWebSocket(t *testing.T, server *httptest.Server, origin string) (net.Conn, *bufio.Reader, *http.Response) {
+	t.Helper()
+	conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	if origin != "" {
+		origin = "Origin: " + origin + "\r\n"
+	}
+	_, err = io.WriteString(conn, "GET /rpc HTTP/1.1\r\nHost: localhost\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n"+
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n"+origin+"\r\n")
+	require.NoError(t, err)
+	reader := bufio.NewReader(conn)
+	response, err := http.ReadResponse(reader, nil)
+	require.NoError(t, err)
+	return conn, reader, response
+}
+
+func writeClientFrame(t *testing.T, conn net.Conn, opcode byte, payload string) {
+	t.Helper()
+	mask := [4]byte{1, 2, 3, 4}
+	frame := []byte{0x80 | opcode}
+	if len(payload) <= 125 {
+		frame = append(frame, 0x80|byte(len(payload)))
+	} else {
+		frame = append(frame, 0x80|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(len(payload)))
+	}
+	frame = append(frame, mask[:]...)
+	for i := range payload {
+		frame = append(frame, payload[i]^mask[i%4])
+	}
+	_, err := conn.Write(frame)
+	require.NoError(t, err)
+}
+
+func readServerFrame(t *testing.T, reader *bufio.Reader) (byte, string) {
+	t.Helper()
+	var header [2]byte
+	_, err := io.ReadFull(reader, header[:])
+	require.NoError(t, err)
+	length := int(header[1] & 0x7F)
+	if length == 126 {
+		var extended [2]byte
+		_, err := io.ReadFull(reader, extended[:])
+		require.NoError(t, err)
+		length = int(binary.BigEndian.Uint16(extended[:]))
+	}
+	payload := make([]byte, length)
+	_, err = io.ReadFull(reader, payload)
+	require.NoError(t, err)
+	return header[0] & 0x0F, string(payload)
+}
+
+func TestWebSocket(t *testing.T) {
+	s := New(WithMaxRequestSize(256))
+	s.Register(NewTypedHandler("echo", echo))
+	server := httptest.NewServer(s)
+	defer server.Close()
+	conn, reader := dialWebSocket(t, server)
+
+	writeClientFrame(t, conn, wsOpText, `{"jsonrpc":"2.0","method":"echo","params":"ignored"}`)
+	writeClientFrame(t, conn, wsOpPing, "hello")
+	opcode, payload := readServerFrame(t, reader)
+	require.Equal(t, byte(wsOpPong), opcode)
+	require.Equal(t, "hello", payload)
+
+	writeClientFrame(t, conn, wsOpText, `{"jsonrpc":"2.0","method":"echo","id":1,"params":"hi"}`)
+	opcode, payload = readServerFrame(t, reader)
+	require.Equal(t, byte(wsOpText), opcode)
+	require.JSONEq(t, `{"jsonrpc":"2.0","result":"hi","id":1}`, payload)
+
+	writeClientFrame(t, conn, wsOpText, `[{"jsonrpc":"2.0","method":"echo","id":2,"params":"a"},{"jsonrpc":"2.0","method":"echo","id":3,"params":"b"}]`)
+	_, payload = readServerFrame(t, reader)
+	require.Contains(t, payload, `"result":"a"`)
+	require.Contains(t, payload, `"result":"b"`)
+
+	writeClientFrame(t, conn, wsOpText, `{"jsonrpc":"2.0","method":"echo","id":4,"params":"`+strings.Repeat("x", 300)+`"}`)
+	opcode, payload = readServerFrame(t, reader)
+	require.Equal(t, byte(wsOpClose), opcode)
+	require.Equal(t, uint16(wsCloseMessageTooLarge), binary.BigEndian.Uint16([]byte(payload)))
+}
+
+func TestWebSocketOrigin(t *testing.T) {
+	s := New(WithWebSocketOrigins("https://app.example.com"))
+	s.Register(NewTypedHandler("echo", echo))
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	_, _, response := upgradeWebSocket(t, server, "https://evil.example.com")
+	require.Equal(t, http.StatusForbidden, response.StatusCode)
+	_, _, response = upgradeWebSocket(t, server, "null")
+	require.Equal(t, http.StatusForbidden, response.StatusCode)
+
+	for _, origin := range []string{"http://localhost", "https://app.example.com"} {
+		conn, reader, response := upgradeWebSocket(t, server, origin)
+		require.Equal(t, http.StatusSwitchingProtocols, response.StatusCode)
+		writeClientFrame(t, conn, wsOpText, `{"jsonrpc":"2.0","method":"echo","id":1,"params":"hi"}`)
+		_, payload := readServerFrame(t, reader)
+		require.JSONEq(t, `{"jsonrpc":"2.0","result":"hi","id":1}`, payload)
+	}
+}
+
+func TestWebSocketNoFrameAfterClose(t *testing.T) {
+	server, client := net.Pipe()
+	received := make(chan []byte)
+	go func() {
+		b, _ := io.ReadAll(client)
+		received <- b
+	}()
+	ws := &wsConn{conn: server}
+	require.NoError(t, ws.writeClose(wsCloseNormal))
+	require.ErrorIs(t, ws.WriteMessage([]byte(`{"jsonrpc":"2.0","result":"late","id":1}`)), errWebSocketClosed)
+	require.ErrorIs(t, ws.writeClose(wsCloseGoingAway), errWebSocketClosed)
+	require.NoError(t, ws.Close())
+	require.Equal(t, []byte{0x80 | wsOpClose, 2, 0x03, 0xE8}, <-received)
+}